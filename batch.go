@@ -0,0 +1,405 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// BatchError reports the entities of a batch that were not written, by their index in the slice
+type BatchError struct {
+	Errors map[int]error
+}
+
+func (e *BatchError) Error() string {
+	indexes := make([]int, 0, len(e.Errors))
+	for i := range e.Errors {
+		indexes = append(indexes, i)
+	}
+	sort.Ints(indexes)
+
+	messages := make([]string, 0, len(indexes))
+	for _, i := range indexes {
+		messages = append(messages, fmt.Sprintf("%d: %s", i, e.Errors[i]))
+	}
+
+	return fmt.Sprintf("%d entities failed: %s", len(indexes), strings.Join(messages, "; "))
+}
+
+func (e *BatchError) Unwrap() []error {
+	errs := make([]error, 0, len(e.Errors))
+	for _, err := range e.Errors {
+		errs = append(errs, err)
+	}
+	return errs
+}
+
+// InsertRows inserts entities with one multi-row insert, filtering each like InsertRow. Generated keys are written
+// back to the entities, auto increment keys are not as MySQL does not guarantee the rows of one insert consecutive
+// ids, entities that need theirs should use generated keys or be reloaded. Entities that fail their filter or hooks
+// are reported in a *BatchError and skipped. With transaction the batch and its hooks run in a transaction that is rolled back if any entity fails
+func InsertRows[T IEntity](db *sql.DB, entities []T, transaction bool) ([]*Result, error) {
+	if len(entities) == 0 {
+		return nil, nil
+	}
+
+	meta := getEntityMeta(typeOf[T]())
+	table := mustGetTable(&entities[0])
+
+	return runBatch(db, transaction, func(ex executor, errs map[int]error) []*Result {
+		rows := make([]map[string]any, 0, len(entities))
+		inserted := make([]int, 0, len(entities))
+
+		for i := range entities {
+			if reflect.ValueOf(entities[i]).IsZero() {
+				panic("Cannot insert zero entity " + meta.Type.String())
+			}
+
+			fields, err := insertFields(ex, table, &entities[i])
+			if err != nil {
+				errs[i] = err
+				continue
+			}
+
+			rows = append(rows, fields)
+			inserted = append(inserted, i)
+		}
+
+		if len(rows) == 0 || (transaction && len(errs) > 0) {
+			return nil
+		}
+
+		r := ex.Exec(NewQuery().InsertInto(table).Set(rows))
+
+		for _, i := range inserted {
+			if err := callHook(ex, &entities[i], IAfterInsert.AfterInsert); err != nil {
+				errs[i] = err
+			}
+		}
+
+		return []*Result{r}
+	})
+}
+
+// UpdateRows updates entities like UpdateRow. Entities that change the same columns are updated together with one
+// CASE WHEN per column, versioned entities are updated one by one so each has its version checked. Entities that
+// fail their filter or hooks or are stale are reported in a *BatchError. With transaction the batch and its hooks
+// run in a transaction that is rolled back if any entity fails
+func UpdateRows[T IEntity](db *sql.DB, entities []T, transaction bool) ([]*Result, error) {
+	if len(entities) == 0 {
+		return nil, nil
+	}
+
+	return runBatch(db, transaction, func(ex executor, errs map[int]error) []*Result {
+		updates := make([]*rowUpdate[T], len(entities))
+		groups := make(map[string][]int)
+		order := make([]string, 0)
+
+		for i := range entities {
+			u, err := newRowUpdate(ex, &entities[i], nil)
+			if err != nil {
+				errs[i] = err
+				continue
+			}
+			if u == nil {
+				continue
+			}
+			updates[i] = u
+
+			group := fmt.Sprint(i)
+			if !u.meta.HasVersion {
+				group = strings.Join(updateColumns(u), ",")
+			}
+
+			if _, has := groups[group]; !has {
+				order = append(order, group)
+			}
+			groups[group] = append(groups[group], i)
+		}
+
+		if transaction && len(errs) > 0 {
+			return nil
+		}
+
+		results := make([]*Result, 0, len(order))
+
+		for _, group := range order {
+			indexes := groups[group]
+
+			var r *Result
+
+			if len(indexes) == 1 {
+				u := updates[indexes[0]]
+				r = ex.Exec(u.query())
+				results = append(results, r)
+
+				if err := u.check(r); err != nil {
+					errs[indexes[0]] = err
+					continue
+				}
+			} else {
+				r = ex.Exec(updateCaseQuery(updates, indexes))
+				results = append(results, r)
+			}
+
+			missing := missingRows(ex, r, updates, indexes)
+
+			for _, i := range indexes {
+				if err, ok := missing[i]; ok {
+					errs[i] = err
+					continue
+				}
+
+				updates[i].apply()
+
+				if err := updates[i].after(ex); err != nil {
+					errs[i] = err
+				}
+			}
+		}
+
+		return results
+	})
+}
+
+// DeleteRows deletes, or soft deletes, entities with one IN on their primary key. Versioned entities are deleted one
+// by one so each has its version checked. Entities that fail their hooks or are stale are reported in a *BatchError
+func DeleteRows[T IEntity](db *sql.DB, entities []T, transaction bool) ([]*Result, error) {
+	if len(entities) == 0 {
+		return nil, nil
+	}
+
+	meta := getEntityMeta(typeOf[T]())
+	mustGetTable(&entities[0])
+
+	return runBatch(db, transaction, func(ex executor, errs map[int]error) []*Result {
+		if meta.HasVersion {
+			results := make([]*Result, 0, len(entities))
+
+			for i := range entities {
+				r, err := deleteRow(ex, &entities[i], meta.SoftDelete == "")
+				if r != nil {
+					results = append(results, r)
+				}
+				if err != nil {
+					errs[i] = err
+				}
+			}
+
+			return results
+		}
+
+		keys := make([]map[string]any, 0, len(entities))
+		deleted := make([]int, 0, len(entities))
+
+		for i := range entities {
+			if reflect.ValueOf(entities[i]).IsZero() {
+				panic("Cannot delete zero entity " + meta.Type.String())
+			}
+
+			if err := callHook(ex, &entities[i], IBeforeDelete.BeforeDelete); err != nil {
+				errs[i] = err
+				continue
+			}
+
+			keys = append(keys, entityKey(meta, entityToMap(&entities[i], false, false, false)))
+			deleted = append(deleted, i)
+		}
+
+		if len(keys) == 0 || (transaction && len(errs) > 0) {
+			return nil
+		}
+
+		q := deleteQuery(ex.db, meta, meta.SoftDelete == "")
+		r := ex.Exec(whereKeys(q, meta, keys))
+
+		for _, i := range deleted {
+			if err := callHook(ex, &entities[i], IAfterDelete.AfterDelete); err != nil {
+				errs[i] = err
+			}
+		}
+
+		return []*Result{r}
+	})
+}
+
+// runBatch runs the statements of a batch, which reports the entities that failed in errs. With transaction, the
+// statements run in a transaction that is rolled back if any entity failed, entities keep the keys and versions
+// written back to them
+func runBatch(db *sql.DB, transaction bool, run func(ex executor, errs map[int]error) []*Result) ([]*Result, error) {
+	errs := make(map[int]error)
+
+	if !transaction {
+		results := run(newExecutor(context.Background(), db, db), errs)
+
+		if len(errs) > 0 {
+			return results, &BatchError{errs}
+		}
+		return results, nil
+	}
+
+	var results []*Result
+
+	err := transact(context.Background(), db, func(ex executor) error {
+		results = run(ex, errs)
+		if len(errs) > 0 {
+			return &BatchError{errs}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
+
+// updateColumns returns the sorted columns an update sets, apart from its primary key
+func updateColumns[T IEntity](u *rowUpdate[T]) []string {
+	columns := make([]string, 0, len(u.fields))
+
+	for c := range u.fields {
+		if _, isKey := u.key[c]; !isKey {
+			columns = append(columns, c)
+		}
+	}
+	sort.Strings(columns)
+
+	return columns
+}
+
+// updateCaseQuery updates entities that set the same columns in one statement, choosing each row's values with CASE
+func updateCaseQuery[T IEntity](updates []*rowUpdate[T], indexes []int) *QueryBuilder {
+	first := updates[indexes[0]]
+	set := make(map[string]any)
+	keys := make([]map[string]any, 0, len(indexes))
+
+	for _, i := range indexes {
+		keys = append(keys, updates[i].key)
+	}
+
+	for _, c := range updateColumns(first) {
+		sql := "CASE"
+		args := make([]any, 0)
+
+		for _, i := range indexes {
+			conditions := make([]string, 0, len(first.meta.PrimaryKeys))
+			for _, pk := range first.meta.PrimaryKeys {
+				conditions = append(conditions, pk.Column+" = ?")
+				args = append(args, updates[i].key[pk.Column])
+			}
+
+			sql += " WHEN " + strings.Join(conditions, " AND ") + " THEN ?"
+			args = append(args, updates[i].fields[c])
+		}
+
+		set[c] = Raw(sql+" ELSE "+c+" END", args...)
+	}
+
+	q := NewQuery().
+		Update(first.table).
+		Set(set)
+
+	return whereKeys(q, first.meta, keys)
+}
+
+// missingRows returns an error for each update whose row does not exist. MySQL does not count the rows an update
+// leaves unchanged, so when fewer rows were affected than updated the keys are looked up
+func missingRows[T IEntity](ex executor, r *Result, updates []*rowUpdate[T], indexes []int) map[int]error {
+	missing := make(map[int]error)
+
+	n, err := r.RowsAffected()
+	if err != nil {
+		for _, i := range indexes {
+			missing[i] = err
+		}
+		return missing
+	}
+
+	if n >= int64(len(indexes)) {
+		return missing
+	}
+
+	first := updates[indexes[0]]
+	columns := make([]any, 0, len(first.meta.PrimaryKeys))
+	keys := make([]map[string]any, 0, len(indexes))
+
+	for _, pk := range first.meta.PrimaryKeys {
+		columns = append(columns, pk.Column)
+	}
+	for _, i := range indexes {
+		keys = append(keys, updates[i].key)
+	}
+
+	rows := ex.Query(whereKeys(NewQuery().Select(columns...).From(first.table), first.meta, keys))
+	defer rows.Close()
+
+	found := make(map[string]bool)
+	for rows.Next() {
+		values := make([]any, len(columns))
+		dest := make([]any, len(columns))
+		for j := range values {
+			dest[j] = &values[j]
+		}
+
+		if err := rows.Scan(dest...); err != nil {
+			panic(err)
+		}
+
+		found[rowKey(values)] = true
+	}
+
+	if err := rows.Err(); err != nil {
+		panic(err)
+	}
+
+	for _, i := range indexes {
+		values := make([]any, 0, len(first.meta.PrimaryKeys))
+		for _, pk := range first.meta.PrimaryKeys {
+			values = append(values, updates[i].key[pk.Column])
+		}
+
+		if !found[rowKey(values)] {
+			missing[i] = fmt.Errorf("%w: %s %v", ErrEntityNotFound, first.table, keyValue(first.meta, updates[i].key))
+		}
+	}
+
+	return missing
+}
+
+// rowKey formats key values the way the driver writes them, so scanned and written keys compare equal
+func rowKey(values []any) string {
+	parts := make([]string, 0, len(values))
+	for _, v := range values {
+		parts = append(parts, fmt.Sprint(comparableValue(v)))
+	}
+	return strings.Join(parts, "\x00")
+}
+
+// whereKeys adds a condition matching any of the primary keys, an IN for single column keys
+func whereKeys(q *QueryBuilder, meta *entityMeta, keys []map[string]any) *QueryBuilder {
+	if len(meta.PrimaryKeys) == 1 {
+		column := meta.PrimaryKeys[0].Column
+		values := make([]any, 0, len(keys))
+
+		for _, key := range keys {
+			values = append(values, key[column])
+		}
+
+		return q.WhereIn(column, values)
+	}
+
+	or := Or()
+	for _, key := range keys {
+		c := Condition()
+		for _, pk := range meta.PrimaryKeys {
+			c.Eq(pk.Column, key[pk.Column])
+		}
+		or.Condition(c)
+	}
+
+	return q.Where(or)
+}
@@ -0,0 +1,67 @@
+package db
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestBatchError(t *testing.T) {
+	err := &BatchError{Errors: map[int]error{
+		3: ErrStaleEntity,
+		1: ErrEntityNotFound,
+	}}
+
+	expected := "2 entities failed: 1: entity not found; 3: stale entity"
+	if err.Error() != expected {
+		t.Errorf("expected %q, got %q", expected, err.Error())
+	}
+
+	if !errors.Is(err, ErrStaleEntity) || !errors.Is(err, ErrEntityNotFound) {
+		t.Error("expected the batch error to wrap the entity errors")
+	}
+}
+
+func TestUpdateCaseQuery(t *testing.T) {
+	meta := getEntityMeta(typeOf[Child]())
+	updates := []*rowUpdate[Child]{
+		{meta: meta, table: "children", fields: map[string]any{"child_id": 1, "child_name": "a"}, key: map[string]any{"child_id": 1}},
+		{meta: meta, table: "children", fields: map[string]any{"child_id": 2, "child_name": "b"}, key: map[string]any{"child_id": 2}},
+	}
+
+	q := updateCaseQuery(updates, []int{0, 1})
+
+	s, args, err := MySQLTranscriber{UsePlaceholders: true}.Transcribe(q)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "UPDATE children SET child_name = CASE WHEN child_id = ? THEN ? WHEN child_id = ? THEN ? ELSE child_name END WHERE child_id IN(?, ?)"
+	if s != expected {
+		t.Errorf("expected %q, got %q", expected, s)
+	}
+
+	if !reflect.DeepEqual(args, []any{1, "a", 2, "b", 1, 2}) {
+		t.Errorf("unexpected args %v", args)
+	}
+}
+
+func TestWhereKeys(t *testing.T) {
+	meta := getEntityMeta(typeOf[ParentFriend]())
+	keys := []map[string]any{
+		{"parent_id": 1, "friend_id": 2},
+		{"parent_id": 1, "friend_id": 3},
+	}
+
+	q := whereKeys(NewQuery().DeleteFrom("parent_friends"), meta, keys)
+
+	s, _, err := (&MySQLTranscriber{}).Transcribe(q)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "DELETE FROM parent_friends WHERE ((parent_id = 1 AND friend_id = 2) OR (parent_id = 1 AND friend_id = 3))"
+	if s != expected {
+		t.Errorf("expected %q, got %q", expected, s)
+	}
+}
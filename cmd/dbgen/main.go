@@ -0,0 +1,542 @@
+// Command dbgen generates reflection-free mappers for structs carrying field, primary, foreign and computed tags.
+//
+// Add a directive next to the entities and run go generate:
+//
+//	//go:generate go run github.com/squlpt-go/db/cmd/dbgen -type Parent,Child
+//
+// Without -type, every struct of the package with at least one field tag is generated. The generated file
+// registers a db.Mapper for each type, which FromRows, FromMap and ToMap use instead of reflection.
+//
+// Types mapped with a naming convention must be generated with the same one, -naming snake or -naming camel, so
+// that untagged fields get the columns db.SnakeCase or db.CamelCase give them. Untagged fields whose type cannot be
+// resolved from the source, such as structs of other packages, must then be tagged field or field:"-".
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"log"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/squlpt-go/db"
+)
+
+const importPath = "github.com/squlpt-go/db"
+
+var namings = map[string]db.Naming{
+	"snake": {Column: db.SnakeCase, Table: db.SnakeCase},
+	"camel": {Column: db.CamelCase, Table: db.CamelCase},
+}
+
+func main() {
+	types := flag.String("type", "", "comma separated list of type names, defaults to every tagged struct")
+	output := flag.String("output", "db_gen.go", "output file name")
+	namingName := flag.String("naming", "", "naming convention of untagged fields, snake or camel")
+	flag.Parse()
+
+	var naming db.Naming
+	if *namingName != "" {
+		var ok bool
+		if naming, ok = namings[*namingName]; !ok {
+			log.Fatalf("dbgen: unknown naming %q", *namingName)
+		}
+	}
+
+	dir := "."
+	if flag.NArg() > 0 {
+		dir = flag.Arg(0)
+	}
+
+	var names []string
+	if *types != "" {
+		names = strings.Split(*types, ",")
+	}
+
+	src, err := generate(dir, names, *output, naming)
+	if err != nil {
+		log.Fatalf("dbgen: %s", err)
+	}
+
+	err = os.WriteFile(filepath.Join(dir, *output), src, 0644)
+	if err != nil {
+		log.Fatalf("dbgen: %s", err)
+	}
+}
+
+type entityField struct {
+	Name     string
+	Column   string
+	Primary  string
+	Foreign  string
+	Computed string
+	Kind     fieldKind
+	Zero     string
+}
+
+type fieldKind int
+
+const (
+	// basicField is a builtin type or a local type based on one, compared to its zero value directly
+	basicField fieldKind = iota
+	// structField may be a struct, nested fields are searched through db.MapperField
+	structField
+	// otherField cannot contain nested fields: pointers, slices, maps...
+	otherField
+)
+
+type entity struct {
+	Name      string
+	HasEntity bool
+	Fields    []entityField
+}
+
+// decls holds the declarations of the parsed package that field types are resolved against
+type decls struct {
+	specs   map[string]*ast.TypeSpec
+	files   map[string]*ast.File
+	methods map[string]map[string]bool
+}
+
+func generate(dir string, names []string, output string, naming db.Naming) ([]byte, error) {
+	fset := token.NewFileSet()
+	pkgs, err := parser.ParseDir(fset, dir, func(fi os.FileInfo) bool {
+		return !strings.HasSuffix(fi.Name(), "_test.go") && fi.Name() != output
+	}, parser.ParseComments)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(pkgs) != 1 {
+		return nil, fmt.Errorf("expected exactly one package in %s, found %d", dir, len(pkgs))
+	}
+
+	var pkg *ast.Package
+	for _, p := range pkgs {
+		pkg = p
+	}
+
+	src := decls{
+		specs:   make(map[string]*ast.TypeSpec),
+		files:   make(map[string]*ast.File),
+		methods: make(map[string]map[string]bool),
+	}
+	order := make([]string, 0)
+
+	files := make([]string, 0)
+	for name := range pkg.Files {
+		files = append(files, name)
+	}
+	sort.Strings(files)
+
+	for _, name := range files {
+		file := pkg.Files[name]
+		ast.Inspect(file, func(n ast.Node) bool {
+			switch n := n.(type) {
+			case *ast.TypeSpec:
+				src.specs[n.Name.Name] = n
+				src.files[n.Name.Name] = file
+				order = append(order, n.Name.Name)
+			case *ast.FuncDecl:
+				if n.Recv != nil && len(n.Recv.List) == 1 {
+					recv := embeddedName(n.Recv.List[0].Type)
+					if src.methods[recv] == nil {
+						src.methods[recv] = make(map[string]bool)
+					}
+					src.methods[recv][n.Name.Name] = true
+				}
+			}
+			return true
+		})
+	}
+
+	if names == nil {
+		for _, name := range order {
+			if st, ok := src.specs[name].Type.(*ast.StructType); ok && src.specs[name].TypeParams == nil && hasTags(st) {
+				names = append(names, name)
+			}
+		}
+	}
+
+	entities := make([]entity, 0, len(names))
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+		spec, ok := src.specs[name]
+		if !ok {
+			return nil, fmt.Errorf("type %s not found", name)
+		}
+
+		st, ok := spec.Type.(*ast.StructType)
+		if !ok || spec.TypeParams != nil {
+			return nil, fmt.Errorf("type %s must be a non generic struct", name)
+		}
+
+		e, err := src.newEntity(name, st, naming)
+		if err != nil {
+			return nil, err
+		}
+		entities = append(entities, e)
+	}
+
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "// Code generated by dbgen; DO NOT EDIT.\n\npackage %s\n\nimport \"%s\"\n", pkg.Name, importPath)
+
+	for _, e := range entities {
+		e.write(&b)
+	}
+
+	return format.Source(b.Bytes())
+}
+
+func hasTags(st *ast.StructType) bool {
+	for _, f := range st.Fields.List {
+		tag := fieldTag(f)
+		if tag.Get("field") != "" || tag.Get("primary") != "" || tag.Get("foreign") != "" || tag.Get("computed") != "" {
+			return true
+		}
+	}
+	return false
+}
+
+func fieldTag(f *ast.Field) reflect.StructTag {
+	if f.Tag == nil {
+		return ""
+	}
+	tag, _ := strconv.Unquote(f.Tag.Value)
+	return reflect.StructTag(tag)
+}
+
+var zeroValues = map[string]string{
+	"string": `""`, "bool": "false",
+	"int": "0", "int8": "0", "int16": "0", "int32": "0", "int64": "0",
+	"uint": "0", "uint8": "0", "uint16": "0", "uint32": "0", "uint64": "0",
+	"float32": "0", "float64": "0", "byte": "0", "rune": "0",
+}
+
+// newEntity reads the fields of a struct, naming untagged fields the way the db package does at runtime
+func (s decls) newEntity(name string, st *ast.StructType, naming db.Naming) (entity, error) {
+	e := entity{Name: name}
+	file := s.files[name]
+	namedTable := naming.Table != nil && !hasPrimaryTag(st)
+
+	for _, f := range st.Fields.List {
+		names := make([]string, 0)
+		for _, n := range f.Names {
+			names = append(names, n.Name)
+		}
+
+		if len(names) == 0 {
+			if isEntityPointer(f.Type, importName(file, importPath, "db")) {
+				e.HasEntity = true
+				continue
+			}
+			names = append(names, embeddedName(f.Type))
+		}
+
+		tag := fieldTag(f)
+		kind, zero := classify(f.Type, s.specs)
+
+		column := tag.Get("field")
+		named := false
+
+		if column == "-" {
+			column = ""
+		} else if column == "" && naming.Column != nil && len(f.Names) > 0 && tag.Get("computed") == "" {
+			var known bool
+			if named, known = s.holdsColumn(f.Type, file); !known {
+				return e, fmt.Errorf("%s.%s: cannot tell whether the untagged field is a column, tag it field:\"<column>\" or field:\"-\"", name, names[0])
+			}
+		}
+
+		for _, n := range names {
+			if n == "_" {
+				continue
+			}
+
+			ef := entityField{
+				Name:     n,
+				Column:   column,
+				Primary:  tag.Get("primary"),
+				Foreign:  tag.Get("foreign"),
+				Computed: tag.Get("computed"),
+				Kind:     kind,
+				Zero:     zero,
+			}
+
+			if named && ast.IsExported(n) {
+				ef.Column = naming.Column(n)
+			}
+
+			if namedTable && n == "ID" && ef.Column != "" {
+				ef.Primary = naming.Table(name)
+			}
+
+			e.Fields = append(e.Fields, ef)
+		}
+	}
+
+	return e, nil
+}
+
+func hasPrimaryTag(st *ast.StructType) bool {
+	for _, f := range st.Fields.List {
+		if fieldTag(f).Get("primary") != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// importName returns the name a file imports a package under, or an empty string when it does not import it
+func importName(file *ast.File, path string, name string) string {
+	if file == nil {
+		return ""
+	}
+
+	for _, imp := range file.Imports {
+		if p, _ := strconv.Unquote(imp.Path.Value); p == path {
+			if imp.Name != nil {
+				return imp.Name.Name
+			}
+			return name
+		}
+	}
+
+	return ""
+}
+
+// isEntityPointer reports whether an embedded field is *db.Entity, dbName being the name the file imports db under
+func isEntityPointer(expr ast.Expr, dbName string) bool {
+	star, ok := expr.(*ast.StarExpr)
+	if !ok {
+		return false
+	}
+
+	sel, ok := star.X.(*ast.SelectorExpr)
+	if !ok || sel.Sel.Name != "Entity" {
+		return false
+	}
+
+	pkg, ok := sel.X.(*ast.Ident)
+	return ok && dbName != "" && pkg.Name == dbName
+}
+
+// columnTypes are the types of the db package holding a column value, relations and the Entity embed do not
+var columnTypes = map[string]bool{
+	"Nullable": true, "Json": true, "Regexp": true, "UUID": true, "BinaryUUID": true, "ULID": true,
+	"Decimal": true, "Enum": true, "Encrypted": true, "Deterministic": true,
+	"HasMany": false, "BelongsTo": false, "Entity": false,
+}
+
+// holdsColumn follows the rules the db package applies to untagged fields at runtime: builtin values, []byte,
+// time.Time and types implementing driver.Valuer or sql.Scanner hold a column, structs, relations and other
+// composite types do not. The second result is false when the type cannot be resolved from the source
+func (s decls) holdsColumn(expr ast.Expr, file *ast.File) (bool, bool) {
+	switch t := expr.(type) {
+	case *ast.Ident:
+		if _, ok := zeroValues[t.Name]; ok {
+			return true, true
+		}
+
+		switch t.Name {
+		case "uintptr", "complex64", "complex128":
+			return true, true
+		case "error", "any":
+			return false, true
+		}
+
+		spec, ok := s.specs[t.Name]
+		if !ok {
+			return false, false
+		}
+
+		if s.methods[t.Name]["Value"] || s.methods[t.Name]["Scan"] {
+			return true, true
+		}
+
+		if _, isStruct := spec.Type.(*ast.StructType); isStruct {
+			return false, true
+		}
+
+		return s.holdsColumn(spec.Type, s.files[t.Name])
+	case *ast.SelectorExpr:
+		pkg, ok := t.X.(*ast.Ident)
+		if !ok {
+			return false, false
+		}
+
+		if pkg.Name == importName(file, "time", "time") && t.Sel.Name == "Time" {
+			return true, true
+		}
+
+		if pkg.Name == importName(file, importPath, "db") {
+			column, known := columnTypes[t.Sel.Name]
+			return column, known
+		}
+
+		return false, false
+	case *ast.IndexExpr:
+		return s.holdsColumn(t.X, file)
+	case *ast.IndexListExpr:
+		return s.holdsColumn(t.X, file)
+	case *ast.StarExpr:
+		return s.holdsColumn(t.X, file)
+	case *ast.ArrayType:
+		if elem, ok := t.Elt.(*ast.Ident); ok && t.Len == nil {
+			return elem.Name == "byte" || elem.Name == "uint8", true
+		}
+		return false, true
+	case *ast.MapType, *ast.FuncType, *ast.ChanType, *ast.InterfaceType, *ast.StructType:
+		return false, true
+	}
+
+	return false, false
+}
+
+func embeddedName(expr ast.Expr) string {
+	switch t := expr.(type) {
+	case *ast.Ident:
+		return t.Name
+	case *ast.SelectorExpr:
+		return t.Sel.Name
+	case *ast.StarExpr:
+		return embeddedName(t.X)
+	case *ast.IndexExpr:
+		return embeddedName(t.X)
+	case *ast.IndexListExpr:
+		return embeddedName(t.X)
+	}
+	return "_"
+}
+
+func classify(expr ast.Expr, specs map[string]*ast.TypeSpec) (fieldKind, string) {
+	switch t := expr.(type) {
+	case *ast.Ident:
+		if zero, ok := zeroValues[t.Name]; ok {
+			return basicField, zero
+		}
+		if spec, ok := specs[t.Name]; ok && spec.TypeParams == nil {
+			if _, isStruct := spec.Type.(*ast.StructType); isStruct {
+				return structField, ""
+			}
+			return classify(spec.Type, specs)
+		}
+		return otherField, ""
+	case *ast.SelectorExpr, *ast.IndexExpr, *ast.IndexListExpr, *ast.StructType:
+		return structField, ""
+	}
+	return otherField, ""
+}
+
+func (e entity) constant(f entityField) string {
+	return e.Name + "Column" + f.Name
+}
+
+func (e entity) write(b *bytes.Buffer) {
+	var table, primaryKey string
+	constants := make([]string, 0)
+
+	for _, f := range e.Fields {
+		if f.Primary != "" && table == "" {
+			table = f.Primary
+			primaryKey = e.constant(f)
+			constants = append(constants, fmt.Sprintf("%sTable = %q\n", e.Name, table))
+		}
+	}
+	for _, f := range e.Fields {
+		if f.Column != "" {
+			constants = append(constants, fmt.Sprintf("%s = %q\n", e.constant(f), f.Column))
+		}
+	}
+
+	if len(constants) > 0 {
+		fmt.Fprintf(b, "\nconst (\n%s)\n", strings.Join(constants, ""))
+	}
+
+	fmt.Fprintf(b, "\nfunc init() {\ndb.RegisterMapper(db.Mapper[%s]{\n", e.Name)
+
+	if table != "" {
+		fmt.Fprintf(b, "Table: %sTable,\nPrimaryKey: %s,\n", e.Name, primaryKey)
+	}
+
+	columns := make([]string, 0)
+	for _, f := range e.Fields {
+		if f.Column != "" {
+			columns = append(columns, e.constant(f))
+		}
+	}
+	fmt.Fprintf(b, "Columns: []string{%s},\n", strings.Join(columns, ", "))
+
+	if e.HasEntity {
+		fmt.Fprintf(b, "Entity: func(e *%s) **db.Entity {\nreturn &e.Entity\n},\n", e.Name)
+	}
+
+	e.writeField(b)
+	e.writeToMap(b)
+
+	fmt.Fprintf(b, "})\n}\n")
+}
+
+func (e entity) writeField(b *bytes.Buffer) {
+	fmt.Fprintf(b, "Field: func(e *%s, column string, readOnly bool) (any, bool) {\n", e.Name)
+
+	seen := make(map[string]bool)
+	direct := make([]string, 0)
+	computed := make([]string, 0)
+
+	for _, f := range e.Fields {
+		if f.Column != "" && f.Foreign == "" && !seen[f.Column] {
+			seen[f.Column] = true
+			direct = append(direct, fmt.Sprintf("case %s:\nreturn &e.%s, true\n", e.constant(f), f.Name))
+		}
+	}
+	for _, f := range e.Fields {
+		if f.Computed != "" && !seen[f.Computed] {
+			seen[f.Computed] = true
+			computed = append(computed, fmt.Sprintf("case %q:\nreturn &e.%s, true\n", f.Computed, f.Name))
+		}
+	}
+
+	if len(direct) > 0 {
+		fmt.Fprintf(b, "switch column {\n%s}\n", strings.Join(direct, ""))
+	}
+	if len(computed) > 0 {
+		fmt.Fprintf(b, "if readOnly {\nswitch column {\n%s}\n}\n", strings.Join(computed, ""))
+	}
+
+	for _, f := range e.Fields {
+		if f.Kind == structField {
+			fmt.Fprintf(b, "if f, ok := db.MapperField(&e.%s, column, readOnly); ok {\nreturn f, true\n}\n", f.Name)
+		}
+	}
+
+	fmt.Fprintf(b, "return nil, false\n},\n")
+}
+
+func (e entity) writeToMap(b *bytes.Buffer) {
+	fmt.Fprintf(b, "ToMap: func(e *%s, values map[string]any, onlyUpdated bool, unserialize bool, recurse bool) {\n", e.Name)
+
+	for _, f := range e.Fields {
+		if f.Column == "" {
+			continue
+		}
+
+		if f.Kind == basicField {
+			fmt.Fprintf(b, "if !onlyUpdated || e.%s != %s || db.MapperKeepZero(values, %s) {\nvalues[%s] = e.%s\n}\n",
+				f.Name, f.Zero, e.constant(f), e.constant(f), f.Name)
+		} else {
+			fmt.Fprintf(b, "db.MapperValue(values, %s, &e.%s, onlyUpdated, unserialize, recurse)\n", e.constant(f), f.Name)
+		}
+	}
+
+	fmt.Fprintf(b, "},\n")
+}
@@ -0,0 +1,203 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/squlpt-go/db"
+)
+
+const source = `package ents
+
+import (
+	"time"
+
+	"github.com/squlpt-go/db"
+)
+
+type status string
+
+type Parent struct {
+	*db.Entity
+	ID        int64               ` + "`field:\"parent_id\" primary:\"parents\"`" + `
+	Name      db.Nullable[string] ` + "`field:\"parent_name\"`" + `
+	Status    status              ` + "`field:\"parent_status\"`" + `
+	Timestamp time.Time
+	Total     int ` + "`computed:\"total\"`" + `
+}
+
+type Child struct {
+	*db.Entity
+	Parent Parent ` + "`field:\"parent_id\" foreign:\"parents\"`" + `
+	ID     int64  ` + "`field:\"child_id\" primary:\"children\"`" + `
+}
+
+type untagged struct {
+	Name string
+}
+`
+
+func TestGenerate(t *testing.T) {
+	dir := t.TempDir()
+	err := os.WriteFile(filepath.Join(dir, "ents.go"), []byte(source), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	src, err := generate(dir, nil, "db_gen.go", db.Naming{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out := string(src)
+	expected := []string{
+		"package ents",
+		`ParentTable        = "parents"`,
+		`ChildColumnParent = "parent_id"`,
+		"db.RegisterMapper(db.Mapper[Parent]{",
+		"PrimaryKey: ChildColumnID,",
+		"return &e.Entity",
+		"case ParentColumnStatus:\n\t\t\t\treturn &e.Status, true",
+		"case \"total\":\n\t\t\t\t\treturn &e.Total, true",
+		"db.MapperField(&e.Parent, column, readOnly)",
+		"db.MapperField(&e.Timestamp, column, readOnly)",
+		"e.Status != \"\" || db.MapperKeepZero(values, ParentColumnStatus)",
+		"db.MapperValue(values, ParentColumnName, &e.Name, onlyUpdated, unserialize, recurse)",
+	}
+
+	for _, s := range expected {
+		if !strings.Contains(out, s) {
+			t.Errorf("expected generated code to contain %q\n%s", s, out)
+		}
+	}
+
+	if strings.Contains(out, "case ChildColumnParent") {
+		t.Error("foreign fields should be resolved through the related entity")
+	}
+
+	if strings.Contains(out, "untagged") {
+		t.Error("untagged structs should not be generated")
+	}
+}
+
+func TestGenerate_Types(t *testing.T) {
+	dir := t.TempDir()
+	err := os.WriteFile(filepath.Join(dir, "ents.go"), []byte(source), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	src, err := generate(dir, []string{"Child"}, "db_gen.go", db.Naming{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if strings.Contains(string(src), "Mapper[Parent]") {
+		t.Error("only the requested types should be generated")
+	}
+
+	_, err = generate(dir, []string{"Missing"}, "db_gen.go", db.Naming{})
+	if err == nil {
+		t.Error("expected an error for an unknown type")
+	}
+}
+
+const namedSource = `package ents
+
+import (
+	"time"
+
+	sq "github.com/squlpt-go/db"
+	other "example.com/other"
+)
+
+type Account struct {
+	*sq.Entity
+	ID        int64
+	FullName  sq.Nullable[string]
+	CreatedAt time.Time
+	Avatar    []byte
+	Hidden    string ` + "`field:\"-\"`" + `
+	Total     int    ` + "`computed:\"total\"`" + `
+	Tags      map[string]bool
+	Owner     sq.BelongsTo[Account]
+}
+
+type Membership struct {
+	*other.Entity
+	Account Account ` + "`foreign:\"account\"`" + `
+}
+
+type Ambiguous struct {
+	ID    int64
+	Other other.Thing
+}
+`
+
+func TestGenerate_Naming(t *testing.T) {
+	dir := t.TempDir()
+	err := os.WriteFile(filepath.Join(dir, "ents.go"), []byte(namedSource), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	src, err := generate(dir, []string{"Account", "Membership"}, "db_gen.go", db.Naming{Column: db.SnakeCase, Table: db.SnakeCase})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out := string(src)
+	expected := []string{
+		`AccountTable           = "account"`,
+		`AccountColumnID        = "id"`,
+		`AccountColumnFullName  = "full_name"`,
+		`AccountColumnCreatedAt = "created_at"`,
+		`AccountColumnAvatar    = "avatar"`,
+		"[]string{AccountColumnID, AccountColumnFullName, AccountColumnCreatedAt, AccountColumnAvatar},",
+		"return &e.Entity",
+	}
+
+	for _, s := range expected {
+		if !strings.Contains(out, s) {
+			t.Errorf("expected generated code to contain %q\n%s", s, out)
+		}
+	}
+
+	for _, s := range []string{"AccountColumnHidden", "AccountColumnTags", "AccountColumnOwner", "AccountColumnTotal"} {
+		if strings.Contains(out, s) {
+			t.Errorf("expected no %s column\n%s", s, out)
+		}
+	}
+
+	membership := out[strings.Index(out, "db.Mapper[Membership]"):]
+	if strings.Contains(membership, "return &e.Entity") {
+		t.Error("an Entity of another package should not be mapped as the db embed")
+	}
+
+	if _, err = generate(dir, []string{"Ambiguous"}, "db_gen.go", db.Naming{Column: db.SnakeCase}); err == nil || !strings.Contains(err.Error(), "Ambiguous.Other") {
+		t.Errorf("expected an error for an untagged field of an unknown type, got %v", err)
+	}
+
+	if _, err = generate(dir, []string{"Ambiguous"}, "db_gen.go", db.Naming{}); err != nil {
+		t.Errorf("untagged fields should be ignored without a naming convention, got %v", err)
+	}
+}
+
+func TestGenerate_ForeignOnly(t *testing.T) {
+	dir := t.TempDir()
+	err := os.WriteFile(filepath.Join(dir, "ents.go"), []byte(namedSource), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	src, err := generate(dir, nil, "db_gen.go", db.Naming{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(string(src), "db.Mapper[Membership]") {
+		t.Error("structs with only foreign tags should be generated")
+	}
+}
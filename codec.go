@@ -0,0 +1,826 @@
+package db
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"time"
+)
+
+// QueryJSONVersion is the version of the encoding written by MarshalJSON
+const QueryJSONVersion = 1
+
+var (
+	ErrRawNotAllowed       = errors.New("raw SQL is not allowed")
+	ErrUnsupportedVersion  = errors.New("unsupported query encoding version")
+	ErrInvalidQueryEncoded = errors.New("invalid query encoding")
+)
+
+// encodedPart is a plain or backquoted name, encodedIdent is a column, table.column or *, optionally aliased with AS
+// as the builder writes aliased fields and joins
+const encodedPart = "([A-Za-z_$][\\w$]*|`([^`]|``)+`)"
+
+var (
+	encodedIdent = regexp.MustCompile(`^(\*|` + encodedPart + `(\.(` + encodedPart + `|\*))?)(\s+(?i:AS)\s+` + encodedPart + `)?$`)
+	encodedName  = regexp.MustCompile(`^` + encodedPart + `$`)
+)
+
+type jsonValue struct {
+	Kind  string          `json:"kind"`
+	Value json.RawMessage `json:"value,omitempty"`
+	List  []jsonValue     `json:"list,omitempty"`
+	Query *jsonQuery      `json:"query,omitempty"`
+	Args  []jsonValue     `json:"args,omitempty"`
+}
+
+type jsonCondition struct {
+	Kind       string          `json:"kind"`
+	Not        bool            `json:"not,omitempty"`
+	Left       *jsonValue      `json:"left,omitempty"`
+	Right      *jsonValue      `json:"right,omitempty"`
+	Value      *jsonValue      `json:"value,omitempty"`
+	Conj       Conj            `json:"conj,omitempty"`
+	Conditions []jsonCondition `json:"conditions,omitempty"`
+}
+
+type jsonIndexHint struct {
+	Type    IndexHintType `json:"type"`
+	Indexes []string      `json:"indexes"`
+}
+
+type jsonJoin struct {
+	Type      JoinType        `json:"type"`
+	Table     jsonValue       `json:"table"`
+	Condition *jsonCondition  `json:"condition,omitempty"`
+	Hints     []jsonIndexHint `json:"hints,omitempty"`
+}
+
+type jsonOrder struct {
+	Field jsonValue `json:"field"`
+	Ord   Ord       `json:"ord"`
+}
+
+type jsonUnion struct {
+	Type  UnionType `json:"type"`
+	Query jsonQuery `json:"query"`
+}
+
+type jsonOffset struct {
+	Start uint `json:"start"`
+	Limit uint `json:"limit"`
+}
+
+type jsonQuery struct {
+	Type            QueryType              `json:"type,omitempty"`
+	Fields          []jsonValue            `json:"fields,omitempty"`
+	FieldsCleared   bool                   `json:"fields_cleared,omitempty"`
+	Values          map[string]jsonValue   `json:"values,omitempty"`
+	ValueRows       []map[string]jsonValue `json:"value_rows,omitempty"`
+	PrimaryTable    *jsonValue             `json:"primary_table,omitempty"`
+	PrimaryHints    []jsonIndexHint        `json:"primary_hints,omitempty"`
+	OptimizerHints  []string               `json:"optimizer_hints,omitempty"`
+	Alias           Ident                  `json:"alias,omitempty"`
+	Joins           []jsonJoin             `json:"joins,omitempty"`
+	Where           *jsonCondition         `json:"where,omitempty"`
+	GroupBys        []jsonValue            `json:"group_bys,omitempty"`
+	Having          *jsonCondition         `json:"having,omitempty"`
+	OrderBys        []jsonOrder            `json:"order_bys,omitempty"`
+	OrderBysCleared bool                   `json:"order_bys_cleared,omitempty"`
+	Offset          jsonOffset             `json:"offset"`
+	Unions          []jsonUnion            `json:"unions,omitempty"`
+	Returnings      []jsonValue            `json:"returnings,omitempty"`
+	Trashed         TrashedScope           `json:"trashed,omitempty"`
+}
+
+type jsonQueryEnvelope struct {
+	Version   int            `json:"version"`
+	Query     *jsonQuery     `json:"query,omitempty"`
+	Condition *jsonCondition `json:"condition,omitempty"`
+}
+
+func (q *QueryBuilder) MarshalJSON() ([]byte, error) {
+	e, err := encodeQuery(q)
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(jsonQueryEnvelope{Version: QueryJSONVersion, Query: e})
+}
+
+// UnmarshalJSON decodes a query, rejecting Raw fragments. Use a QueryDecoder to allow them.
+func (q *QueryBuilder) UnmarshalJSON(data []byte) error {
+	d, err := QueryDecoder{}.DecodeQuery(data)
+	if err != nil {
+		return err
+	}
+
+	*q = *d
+	return nil
+}
+
+func (c *ConditionSet) MarshalJSON() ([]byte, error) {
+	e, err := encodeCondition(c)
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(jsonQueryEnvelope{Version: QueryJSONVersion, Condition: e})
+}
+
+// UnmarshalJSON decodes a condition set, rejecting Raw fragments. Use a QueryDecoder to allow them.
+func (c *ConditionSet) UnmarshalJSON(data []byte) error {
+	d, err := QueryDecoder{}.DecodeCondition(data)
+	if err != nil {
+		return err
+	}
+
+	*c = *d
+	return nil
+}
+
+type QueryDecoder struct {
+	AllowRaw bool
+}
+
+func (d QueryDecoder) DecodeQuery(data []byte) (*QueryBuilder, error) {
+	var e jsonQueryEnvelope
+	err := json.Unmarshal(data, &e)
+	if err != nil {
+		return nil, err
+	}
+
+	if e.Version != QueryJSONVersion {
+		return nil, fmt.Errorf("%w: %d", ErrUnsupportedVersion, e.Version)
+	}
+
+	if e.Query == nil {
+		return nil, fmt.Errorf("%w: missing query", ErrInvalidQueryEncoded)
+	}
+
+	return d.decodeQuery(e.Query)
+}
+
+func (d QueryDecoder) DecodeCondition(data []byte) (*ConditionSet, error) {
+	var e jsonQueryEnvelope
+	err := json.Unmarshal(data, &e)
+	if err != nil {
+		return nil, err
+	}
+
+	if e.Version != QueryJSONVersion {
+		return nil, fmt.Errorf("%w: %d", ErrUnsupportedVersion, e.Version)
+	}
+
+	if e.Condition == nil {
+		return nil, fmt.Errorf("%w: missing condition", ErrInvalidQueryEncoded)
+	}
+
+	c, err := d.decodeCondition(e.Condition)
+	if err != nil {
+		return nil, err
+	}
+
+	if s, ok := c.(*ConditionSet); ok {
+		return s, nil
+	}
+
+	return nil, fmt.Errorf("%w: expected a condition set", ErrInvalidQueryEncoded)
+}
+
+func encodeQuery(q *QueryBuilder) (*jsonQuery, error) {
+	if q.err != nil {
+		return nil, q.err
+	}
+
+	var err error
+	e := &jsonQuery{
+		Type:            q.Type,
+		FieldsCleared:   q.FieldsCleared,
+		OptimizerHints:  q.OptimizerHints,
+		Alias:           q.Alias,
+		OrderBysCleared: q.OrderBysCleared,
+		Offset:          jsonOffset{q.Offset.Start, q.Offset.Limit},
+		Trashed:         q.Trashed,
+	}
+
+	e.Fields, err = encodeValues(q.Fields)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(q.Values) > 0 {
+		e.Values = make(map[string]jsonValue)
+		for k, v := range q.Values {
+			ev, err := encodeValue(RValue(v))
+			if err != nil {
+				return nil, err
+			}
+			e.Values[k] = *ev
+		}
+	}
+
+	for _, row := range q.ValueRows {
+		er := make(map[string]jsonValue)
+		for k, v := range row {
+			ev, err := encodeValue(RValue(v))
+			if err != nil {
+				return nil, err
+			}
+			er[k] = *ev
+		}
+		e.ValueRows = append(e.ValueRows, er)
+	}
+
+	if q.PrimaryTable != nil {
+		e.PrimaryTable, err = encodeValue(q.PrimaryTable)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	e.PrimaryHints = encodeIndexHints(q.PrimaryHints)
+
+	for _, j := range q.Joins {
+		t, err := encodeValue(j.Table)
+		if err != nil {
+			return nil, err
+		}
+		ej := jsonJoin{Type: j.JoinType, Table: *t, Hints: encodeIndexHints(j.Hints)}
+		if j.Condition != nil {
+			ej.Condition, err = encodeCondition(j.Condition)
+			if err != nil {
+				return nil, err
+			}
+		}
+		e.Joins = append(e.Joins, ej)
+	}
+
+	if q.WhereCondition != nil {
+		e.Where, err = encodeCondition(q.WhereCondition)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	e.GroupBys, err = encodeValues(q.GroupBys)
+	if err != nil {
+		return nil, err
+	}
+
+	e.Returnings, err = encodeValues(q.Returnings)
+	if err != nil {
+		return nil, err
+	}
+
+	if q.HavingCondition != nil {
+		e.Having, err = encodeCondition(q.HavingCondition)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	for _, o := range q.OrderBys {
+		f, err := encodeValue(o.Field)
+		if err != nil {
+			return nil, err
+		}
+		e.OrderBys = append(e.OrderBys, jsonOrder{*f, o.Ord})
+	}
+
+	for _, u := range q.Unions {
+		eu, err := encodeQuery(u.Query)
+		if err != nil {
+			return nil, err
+		}
+		e.Unions = append(e.Unions, jsonUnion{u.UnionType, *eu})
+	}
+
+	return e, nil
+}
+
+func encodeIndexHints(hints []IndexHint) []jsonIndexHint {
+	var out []jsonIndexHint
+	for _, h := range hints {
+		out = append(out, jsonIndexHint{h.Type, h.Indexes})
+	}
+	return out
+}
+
+func encodeValues(values []Value) ([]jsonValue, error) {
+	var out []jsonValue
+	for _, v := range values {
+		ev, err := encodeValue(v)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, *ev)
+	}
+	return out, nil
+}
+
+func encodeScalar(kind string, v any) (*jsonValue, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return &jsonValue{Kind: kind, Value: b}, nil
+}
+
+func encodeValue(value Value) (*jsonValue, error) {
+	switch v := value.(type) {
+	case Ident:
+		return encodeScalar("ident", string(v))
+	case String:
+		return encodeScalar("string", string(v))
+	case Int:
+		return encodeScalar("int", int64(v))
+	case Float:
+		if math.IsNaN(float64(v)) || math.IsInf(float64(v), 0) {
+			return nil, fmt.Errorf("%w: cannot encode float %v", ErrInvalidQueryEncoded, float64(v))
+		}
+		return encodeScalar("float", float64(v))
+	case Decimal:
+		return encodeScalar("decimal", v.String())
+	case Bool:
+		return encodeScalar("bool", bool(v))
+	case Time:
+		return encodeScalar("time", time.Time(v).Format(time.RFC3339Nano))
+	case Null:
+		return &jsonValue{Kind: "null"}, nil
+	case List:
+		l, err := encodeValues(v)
+		if err != nil {
+			return nil, err
+		}
+		if l == nil {
+			l = make([]jsonValue, 0)
+		}
+		return &jsonValue{Kind: "list", List: l}, nil
+	case *QueryBuilder:
+		q, err := encodeQuery(v)
+		if err != nil {
+			return nil, err
+		}
+		return &jsonValue{Kind: "query", Query: q}, nil
+	case RawQuery:
+		e, err := encodeScalar("raw", v.Query)
+		if err != nil {
+			return nil, err
+		}
+		for _, a := range v.Args {
+			ea, err := encodeValue(RValue(a))
+			if err != nil {
+				return nil, err
+			}
+			e.Args = append(e.Args, *ea)
+		}
+		return e, nil
+	}
+
+	return nil, fmt.Errorf("%w: cannot encode value of type %T", ErrInvalidQueryEncoded, value)
+}
+
+func encodeCondition(condition any) (*jsonCondition, error) {
+	binary := func(kind string, left Value, right Value, not Neg) (*jsonCondition, error) {
+		l, err := encodeValue(left)
+		if err != nil {
+			return nil, err
+		}
+		r, err := encodeValue(right)
+		if err != nil {
+			return nil, err
+		}
+		return &jsonCondition{Kind: kind, Left: l, Right: r, Not: bool(not)}, nil
+	}
+
+	unary := func(kind string, value Value, not Neg) (*jsonCondition, error) {
+		v, err := encodeValue(value)
+		if err != nil {
+			return nil, err
+		}
+		return &jsonCondition{Kind: kind, Value: v, Not: bool(not)}, nil
+	}
+
+	switch c := condition.(type) {
+	case Eq:
+		return binary("eq", c.Left, c.Right, c.Not)
+	case Gt:
+		return binary("gt", c.Left, c.Right, c.Not)
+	case GtEq:
+		return binary("gte", c.Left, c.Right, c.Not)
+	case Lt:
+		return binary("lt", c.Left, c.Right, c.Not)
+	case LtEq:
+		return binary("lte", c.Left, c.Right, c.Not)
+	case In:
+		return binary("in", c.Left, c.Right, c.Not)
+	case Like:
+		return binary("like", c.Left, c.Right, c.Not)
+	case IsNull:
+		return unary("is_null", c.Value, c.Not)
+	case IsTrue:
+		return unary("is_true", c.Value, c.Not)
+	case IsFalse:
+		return unary("is_false", c.Value, c.Not)
+	case *ConditionSet:
+		e := &jsonCondition{Kind: "set", Not: bool(c.Not), Conj: c.Conj}
+		for _, sub := range c.Conditions {
+			es, err := encodeCondition(sub)
+			if err != nil {
+				return nil, err
+			}
+			e.Conditions = append(e.Conditions, *es)
+		}
+		return e, nil
+	}
+
+	return nil, fmt.Errorf("%w: cannot encode condition of type %T", ErrInvalidQueryEncoded, condition)
+}
+
+func (d QueryDecoder) decodeQuery(e *jsonQuery) (*QueryBuilder, error) {
+	var err error
+	q := NewQuery()
+
+	switch e.Type {
+	case "", Select, Insert, InsertIgnore, InsertUpdate, Update, Delete:
+		q.Type = e.Type
+	default:
+		return nil, fmt.Errorf("%w: invalid query type %q", ErrInvalidQueryEncoded, e.Type)
+	}
+
+	q.FieldsCleared = e.FieldsCleared
+	q.OptimizerHints = e.OptimizerHints
+	if e.Alias != "" {
+		if err = d.checkIdent(string(e.Alias), encodedName); err != nil {
+			return nil, err
+		}
+	}
+	q.Alias = e.Alias
+	q.OrderBysCleared = e.OrderBysCleared
+	q.Offset = Offset{e.Offset.Start, e.Offset.Limit}
+
+	switch e.Trashed {
+	case TrashedExclude, TrashedWith, TrashedOnly:
+		q.Trashed = e.Trashed
+	default:
+		return nil, fmt.Errorf("%w: invalid trashed scope %q", ErrInvalidQueryEncoded, e.Trashed)
+	}
+
+	for _, f := range e.Fields {
+		v, err := d.decodeValue(&f)
+		if err != nil {
+			return nil, err
+		}
+		q.Fields = append(q.Fields, v)
+	}
+
+	for k, ev := range e.Values {
+		if err = d.checkIdent(k, encodedIdent); err != nil {
+			return nil, err
+		}
+		v, err := d.decodeValue(&ev)
+		if err != nil {
+			return nil, err
+		}
+		q.Values[k] = v
+	}
+
+	for _, er := range e.ValueRows {
+		row := make(map[string]any)
+		for k, ev := range er {
+			if err = d.checkIdent(k, encodedIdent); err != nil {
+				return nil, err
+			}
+			v, err := d.decodeValue(&ev)
+			if err != nil {
+				return nil, err
+			}
+			row[k] = v
+		}
+		q.ValueRows = append(q.ValueRows, row)
+	}
+
+	if e.PrimaryTable != nil {
+		q.PrimaryTable, err = d.decodeValue(e.PrimaryTable)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	q.PrimaryHints, err = d.decodeIndexHints(e.PrimaryHints)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, ej := range e.Joins {
+		switch ej.Type {
+		case LeftJoin, RightJoin, InnerJoin:
+		default:
+			return nil, fmt.Errorf("%w: invalid join type %q", ErrInvalidQueryEncoded, ej.Type)
+		}
+
+		t, err := d.decodeValue(&ej.Table)
+		if err != nil {
+			return nil, err
+		}
+
+		j := Join{JoinType: ej.Type, Table: t}
+
+		if ej.Condition != nil {
+			j.Condition, err = d.decodeConditionSet(ej.Condition)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		j.Hints, err = d.decodeIndexHints(ej.Hints)
+		if err != nil {
+			return nil, err
+		}
+
+		q.Joins = append(q.Joins, j)
+	}
+
+	if e.Where != nil {
+		q.WhereCondition, err = d.decodeConditionSet(e.Where)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	for _, g := range e.GroupBys {
+		v, err := d.decodeValue(&g)
+		if err != nil {
+			return nil, err
+		}
+		q.GroupBys = append(q.GroupBys, v)
+	}
+
+	for _, r := range e.Returnings {
+		v, err := d.decodeValue(&r)
+		if err != nil {
+			return nil, err
+		}
+		q.Returnings = append(q.Returnings, v)
+	}
+
+	if e.Having != nil {
+		q.HavingCondition, err = d.decodeConditionSet(e.Having)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	for _, o := range e.OrderBys {
+		if o.Ord != Asc && o.Ord != Desc {
+			return nil, fmt.Errorf("%w: invalid order %q", ErrInvalidQueryEncoded, o.Ord)
+		}
+		f, err := d.decodeValue(&o.Field)
+		if err != nil {
+			return nil, err
+		}
+		q.OrderBys = append(q.OrderBys, Order{f, o.Ord})
+	}
+
+	for _, u := range e.Unions {
+		if u.Type != UnionDefault && u.Type != UnionAll {
+			return nil, fmt.Errorf("%w: invalid union type %q", ErrInvalidQueryEncoded, u.Type)
+		}
+		uq, err := d.decodeQuery(&u.Query)
+		if err != nil {
+			return nil, err
+		}
+		q.Unions = append(q.Unions, Union{uq, u.Type})
+	}
+
+	return q, nil
+}
+
+// checkIdent rejects identifiers that are not plain or backquoted names, such as columns, table.column or *, and their
+// AS aliases, since they are transcribed verbatim and could otherwise carry SQL that AllowRaw did not permit
+func (d QueryDecoder) checkIdent(s string, pattern *regexp.Regexp) error {
+	if d.AllowRaw || pattern.MatchString(s) {
+		return nil
+	}
+	return fmt.Errorf("%w: %q is not an identifier", ErrRawNotAllowed, s)
+}
+
+func (d QueryDecoder) decodeIndexHints(hints []jsonIndexHint) ([]IndexHint, error) {
+	var out []IndexHint
+	for _, h := range hints {
+		switch h.Type {
+		case UseIndex, ForceIndex, IgnoreIndex:
+		default:
+			return nil, fmt.Errorf("%w: invalid index hint %q", ErrInvalidQueryEncoded, h.Type)
+		}
+		for _, index := range h.Indexes {
+			if err := d.checkIdent(index, encodedName); err != nil {
+				return nil, err
+			}
+		}
+		out = append(out, IndexHint{h.Type, h.Indexes})
+	}
+	return out, nil
+}
+
+func (d QueryDecoder) decodeValue(e *jsonValue) (Value, error) {
+	switch e.Kind {
+	case "ident":
+		var s string
+		err := json.Unmarshal(e.Value, &s)
+		if err != nil {
+			return nil, err
+		}
+		if err = d.checkIdent(s, encodedIdent); err != nil {
+			return nil, err
+		}
+		return Ident(s), Ident(s).ValidateSQL()
+	case "string":
+		var s string
+		err := json.Unmarshal(e.Value, &s)
+		return String(s), err
+	case "int":
+		var i int64
+		err := json.Unmarshal(e.Value, &i)
+		return Int(i), err
+	case "float":
+		var f float64
+		err := json.Unmarshal(e.Value, &f)
+		return Float(f), err
+	case "decimal":
+		var s string
+		err := json.Unmarshal(e.Value, &s)
+		if err != nil {
+			return nil, err
+		}
+		return ParseDecimal(s)
+	case "bool":
+		var b bool
+		err := json.Unmarshal(e.Value, &b)
+		return Bool(b), err
+	case "time":
+		var s string
+		err := json.Unmarshal(e.Value, &s)
+		if err != nil {
+			return nil, err
+		}
+		t, err := time.Parse(time.RFC3339Nano, s)
+		return Time(t), err
+	case "null":
+		return Null{}, nil
+	case "list":
+		l := make(List, 0)
+		for _, ev := range e.List {
+			v, err := d.decodeValue(&ev)
+			if err != nil {
+				return nil, err
+			}
+			l = append(l, v)
+		}
+		return l, nil
+	case "query":
+		if e.Query == nil {
+			return nil, fmt.Errorf("%w: missing subquery", ErrInvalidQueryEncoded)
+		}
+		return d.decodeQuery(e.Query)
+	case "raw":
+		if !d.AllowRaw {
+			return nil, ErrRawNotAllowed
+		}
+		var s string
+		err := json.Unmarshal(e.Value, &s)
+		if err != nil {
+			return nil, err
+		}
+		var args []any
+		for _, ea := range e.Args {
+			a, err := d.decodeValue(&ea)
+			if err != nil {
+				return nil, err
+			}
+			args = append(args, valueToArg(a))
+		}
+		r := Raw(s, args...)
+		return r, r.ValidateSQL()
+	}
+
+	return nil, fmt.Errorf("%w: invalid value kind %q", ErrInvalidQueryEncoded, e.Kind)
+}
+
+// valueToArg converts a decoded scalar back to the plain Go value used as a query argument
+func valueToArg(v Value) any {
+	switch v := v.(type) {
+	case String:
+		return string(v)
+	case Int:
+		return int(v)
+	case Float:
+		return float64(v)
+	case Decimal:
+		return v.String()
+	case Bool:
+		return bool(v)
+	case Time:
+		return time.Time(v)
+	case Null:
+		return nil
+	}
+	return v
+}
+
+func (d QueryDecoder) decodeConditionSet(e *jsonCondition) (*ConditionSet, error) {
+	c, err := d.decodeCondition(e)
+	if err != nil {
+		return nil, err
+	}
+
+	if s, ok := c.(*ConditionSet); ok {
+		return s, nil
+	}
+
+	return nil, fmt.Errorf("%w: expected a condition set", ErrInvalidQueryEncoded)
+}
+
+func (d QueryDecoder) decodeCondition(e *jsonCondition) (any, error) {
+	var left, right, value Value
+	var err error
+
+	if e.Left != nil {
+		left, err = d.decodeValue(e.Left)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if e.Right != nil {
+		right, err = d.decodeValue(e.Right)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if e.Value != nil {
+		value, err = d.decodeValue(e.Value)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	not := Neg(e.Not)
+
+	switch e.Kind {
+	case "eq", "gt", "gte", "lt", "lte", "in", "like":
+		if left == nil || right == nil {
+			return nil, fmt.Errorf("%w: %s condition requires left and right values", ErrInvalidQueryEncoded, e.Kind)
+		}
+	case "is_null", "is_true", "is_false":
+		if value == nil {
+			return nil, fmt.Errorf("%w: %s condition requires a value", ErrInvalidQueryEncoded, e.Kind)
+		}
+	}
+
+	switch e.Kind {
+	case "eq":
+		return Eq{left, right, not}, nil
+	case "gt":
+		return Gt{left, right, not}, nil
+	case "gte":
+		return GtEq{left, right, not}, nil
+	case "lt":
+		return Lt{left, right, not}, nil
+	case "lte":
+		return LtEq{left, right, not}, nil
+	case "in":
+		switch right.(type) {
+		case List, *QueryBuilder:
+		default:
+			return nil, fmt.Errorf("%w: in condition requires a list or subquery", ErrInvalidQueryEncoded)
+		}
+		return In{left, right, not}, nil
+	case "like":
+		return Like{left, right, not}, nil
+	case "is_null":
+		return IsNull{value, not}, nil
+	case "is_true":
+		return IsTrue{value, not}, nil
+	case "is_false":
+		return IsFalse{value, not}, nil
+	case "set":
+		if e.Conj != ConjAnd && e.Conj != ConjOr {
+			return nil, fmt.Errorf("%w: invalid conjunction %q", ErrInvalidQueryEncoded, e.Conj)
+		}
+		s := &ConditionSet{Not: not, Conj: e.Conj, Conditions: make([]any, 0)}
+		for _, ec := range e.Conditions {
+			c, err := d.decodeCondition(&ec)
+			if err != nil {
+				return nil, err
+			}
+			s.Conditions = append(s.Conditions, c)
+		}
+		return s, nil
+	}
+
+	return nil, fmt.Errorf("%w: invalid condition kind %q", ErrInvalidQueryEncoded, e.Kind)
+}
@@ -0,0 +1,198 @@
+package db
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestQueryJSONRoundTrip(t *testing.T) {
+	q := NewQuery().
+		Select("name", NewQuery().Select("name").From("table2").As("alias")).
+		From("users").
+		UseIndex("users_name").
+		OptimizerHint("MAX_EXECUTION_TIME(1000)").
+		LeftJoinEq("roles", "users.role_id", "roles.role_id").
+		JoinHint("roles", IgnoreIndex, "roles_type").
+		WhereIn("category", []string{"A", "B"}).
+		WhereIn("category1", []string{}).
+		WhereIsNotNull("deleted").
+		WhereLike("name", "test%").
+		Where(
+			Or().
+				Eq("age", 17).
+				NotEq("age", 19.5).
+				Gt("created", time.Date(2023, 11, 28, 12, 23, 53, 0, time.UTC)),
+		).
+		WhereNot(Condition().IsTrue("flag").Eq("active", true)).
+		GroupBy("field1").
+		HavingGtEq("field3", 1000).
+		OrderBy("field1", Desc).
+		Limit(10, 20).
+		OnlyTrashed().
+		UnionAll(NewQuery().Select("*").From("table2").WhereEq("x", nil))
+
+	encoded, err := json.Marshal(q)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var decoded QueryBuilder
+	err = json.Unmarshal(encoded, &decoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	reencoded, err := json.Marshal(&decoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(encoded) != string(reencoded) {
+		t.Errorf("encoding did not round trip\n%s\n%s", encoded, reencoded)
+	}
+
+	if decoded.Trashed != TrashedOnly {
+		t.Errorf("expected trashed scope %q, got %q", TrashedOnly, decoded.Trashed)
+	}
+
+	transcriber := MySQLTranscriber{UsePlaceholders: true}
+	s1, a1, err := transcriber.Transcribe(q)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s2, a2, err := transcriber.Transcribe(&decoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if s1 != s2 || !reflect.DeepEqual(a1, a2) {
+		t.Errorf("decoded query transcribes differently\n%s %v\n%s %v", s1, a1, s2, a2)
+	}
+}
+
+func TestQueryJSONRoundTrip_Aliases(t *testing.T) {
+	q := NewQuery().
+		Select("users.name AS user_name", "`order`.`total`", TableField("parents_Into", "parent_name")+" AS Into__parent_name").
+		From("users").
+		LeftJoinEq(Ident("parents AS parents_Into"), "parents_Into.parent_id", "users.parent_id").
+		LeftJoinEq("`order`", "`order`.user_id", "users.user_id")
+
+	encoded, err := json.Marshal(q)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	decoded, err := QueryDecoder{}.DecodeQuery(encoded)
+	if err != nil {
+		t.Fatalf("the builder's aliases should be decoded without AllowRaw, got %v", err)
+	}
+
+	transcriber := MySQLTranscriber{UsePlaceholders: true}
+	s1, _, _ := transcriber.Transcribe(q)
+	s2, _, _ := transcriber.Transcribe(decoded)
+
+	if s1 != s2 {
+		t.Errorf("decoded query transcribes differently\n%s\n%s", s1, s2)
+	}
+}
+
+func TestConditionSetJSONRoundTrip(t *testing.T) {
+	c := Or().Eq("a", 1).Condition(Condition().IsNull("b").NotIn("c", []int{1, 2}))
+
+	encoded, err := json.Marshal(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var decoded ConditionSet
+	err = json.Unmarshal(encoded, &decoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(c, &decoded) {
+		t.Errorf("condition set did not round trip %#v", decoded)
+	}
+}
+
+func TestQueryJSONRejectsRaw(t *testing.T) {
+	q := NewQuery().Select(Raw("MAX(age)")).From("users").WhereEq(Raw("LOWER(name)"), "x").Where(Condition().Eq("a", Raw("NOW() - ?", 5)))
+
+	encoded, err := json.Marshal(q)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var decoded QueryBuilder
+	err = json.Unmarshal(encoded, &decoded)
+	if !errors.Is(err, ErrRawNotAllowed) {
+		t.Errorf("Raw fragments should be rejected by default, got %v", err)
+	}
+
+	allowed, err := QueryDecoder{AllowRaw: true}.DecodeQuery(encoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(allowed.Fields, q.Fields) || !reflect.DeepEqual(allowed.WhereCondition, q.WhereCondition) {
+		t.Error("Raw fragments did not round trip")
+	}
+}
+
+func TestQueryJSONRejectsExpressionIdents(t *testing.T) {
+	aliased := NewQuery().Select("*").From("users")
+	aliased.Alias = "t WHERE 1=1"
+
+	rejected := []*QueryBuilder{
+		NewQuery().Select("*").From("users").WhereEq("1=1 OR (SELECT SLEEP(10))", 1),
+		NewQuery().Select("name, password").From("users"),
+		NewQuery().Select("*").From("users").UseIndex("idx) UNION SELECT 1 --"),
+		NewQuery().Select("*").From("users").LeftJoinEq("roles", "users.role_id", "roles.role_id").JoinHint("roles", UseIndex, "a b"),
+		aliased,
+		NewQuery().Update("users").Set(map[string]any{"a = 1, admin": 1}),
+		NewQuery().Select("name AS n, password").From("users"),
+		NewQuery().Select("`name` UNION SELECT `password`").From("users"),
+		NewQuery().Select("*").From("users AS u WHERE 1").WhereEq("u.id", 1),
+	}
+
+	for _, r := range rejected {
+		encoded, err := json.Marshal(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err = (QueryDecoder{}).DecodeQuery(encoded); !errors.Is(err, ErrRawNotAllowed) {
+			t.Errorf("expected %s to be rejected, got %v", encoded, err)
+		}
+
+		if _, err = (QueryDecoder{AllowRaw: true}).DecodeQuery(encoded); err != nil {
+			t.Errorf("expected AllowRaw to accept %s, got %v", encoded, err)
+		}
+	}
+
+	q := NewQuery().Select("users.*", "roles.name", "*").From("users").LeftJoinEq("roles", "users.role_id", "roles.role_id").UseIndex("PRIMARY")
+
+	encoded, err := json.Marshal(q)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = (QueryDecoder{}).DecodeQuery(encoded); err != nil {
+		t.Errorf("plain identifiers should be accepted, got %v", err)
+	}
+}
+
+func TestQueryJSONVersion(t *testing.T) {
+	_, err := QueryDecoder{}.DecodeQuery([]byte(`{"version":2,"query":{"type":"SELECT"}}`))
+	if !errors.Is(err, ErrUnsupportedVersion) {
+		t.Errorf("expected version error, got %v", err)
+	}
+
+	_, err = QueryDecoder{}.DecodeQuery([]byte(`{"version":1,"query":{"type":"DROP"}}`))
+	if !errors.Is(err, ErrInvalidQueryEncoded) {
+		t.Errorf("expected invalid encoding error, got %v", err)
+	}
+}
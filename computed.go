@@ -0,0 +1,16 @@
+package db
+
+import (
+	"reflect"
+)
+
+// selectComputed selects the SQL expression of every field tagged with both computed:"<alias>" and expr:"<sql>"
+// as its alias, such as CONCAT(first, ' ', last) AS full_name. Expressions are used verbatim, so columns that
+// are ambiguous with joined parents must be qualified with their table. Computed fields are never written
+func selectComputed(q *QueryBuilder, t reflect.Type) *QueryBuilder {
+	for _, f := range getEntityMeta(t).Expressions {
+		q.Select(Raw(f.Expr + " AS " + f.Computed))
+	}
+
+	return q
+}
@@ -0,0 +1,35 @@
+package db
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSelectComputed(t *testing.T) {
+	q := NewQuery().
+		Select(TableField("friends", "*")).
+		From("friends")
+
+	selectComputed(q, reflect.TypeOf(LabeledFriend{}))
+
+	s, _, err := (&MySQLTranscriber{}).Transcribe(q)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "SELECT friends.*, CONCAT('#', friends.friend_id, ' ', friends.friend_name) AS friend_label FROM friends"
+	if s != expected {
+		t.Errorf("expected %q, got %q", expected, s)
+	}
+
+	fields := entityToMap(&LabeledFriend{ID: 1, Name: "Friend 1", Label: "#1 Friend 1"}, false, false, false)
+	if _, has := fields["friend_label"]; has {
+		t.Error("computed columns should not be written")
+	}
+
+	q = NewQuery().From("friends")
+	selectComputed(q, reflect.TypeOf(Friend{}))
+	if len(q.Fields) != 0 {
+		t.Error("entities without expressions should not select anything")
+	}
+}
@@ -1,15 +1,18 @@
 package db
 
 import (
+	"context"
 	"database/sql"
 	"errors"
 	"fmt"
 	"log"
 	"reflect"
+	"time"
 )
 
 var (
 	ErrEntityNotFound = errors.New("entity not found")
+	ErrStaleEntity    = errors.New("stale entity")
 )
 
 func Query[T IEntity](db *sql.DB, query Transcribeable) *Rows[T] {
@@ -22,71 +25,179 @@ func Query[T IEntity](db *sql.DB, query Transcribeable) *Rows[T] {
 	r := As[T](rows)
 	r.Query = q
 	r.Args = args
+	r.db = db
+	r.recordRows = statsEnabled()
 
 	return r
 }
 
 func queryStd(db *sql.DB, query Transcribeable) *sql.Rows {
+	return queryOn(context.Background(), db, db, query)
+}
+
+// queryOn transcribes a query for db and runs it on ex, which is db or one of its transactions
+func queryOn(ctx context.Context, db *sql.DB, ex execer, query Transcribeable) *sql.Rows {
 	q, args, err := query.Transcribe(db)
 	if err != nil {
 		panic(err)
 	}
 
 	writeLog(LogQueries, "QUERY: %s %+v", q, args)
-	rows, err := db.Query(q, args...)
+	start := time.Now()
+	rows, err := ex.QueryContext(ctx, q, args...)
+	recordStats(q, start, 0, err)
 	if err != nil {
-		m := fmt.Sprintf("FAILURE: %s in %s %+v", err, q, args)
-		writeLog(LogFailures, m)
-		panic(m)
+		failStatement(err, q, args)
 	}
 
 	return rows
 }
 
 func Exec(db *sql.DB, query Transcribeable) *Result {
+	return execOn(context.Background(), db, db, query)
+}
+
+type txKey struct{}
+
+// WithTx returns a context that makes the Context variants of the row functions run on tx and its connection
+// instead of a transaction of their own. Committing or rolling back tx is left to the caller
+func WithTx(ctx context.Context, tx *sql.Tx) context.Context {
+	return context.WithValue(ctx, txKey{}, tx)
+}
+
+// inTx runs fn in the transaction of ctx, or in a transaction of its own when ctx has none
+func inTx(ctx context.Context, db *sql.DB, fn func(ex executor) error) error {
+	if tx, ok := ctx.Value(txKey{}).(*sql.Tx); ok {
+		return fn(newExecutor(ctx, db, tx))
+	}
+	return transact(ctx, db, fn)
+}
+
+// transact runs fn in a transaction of db, which is committed when fn returns nil and rolled back otherwise or when
+// it panics
+func transact(ctx context.Context, db *sql.DB, fn func(ex executor) error) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	committed := false
+	defer func() {
+		if !committed {
+			_ = tx.Rollback()
+		}
+	}()
+
+	if err = fn(newExecutor(ctx, db, tx)); err != nil {
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return err
+	}
+	committed = true
+
+	return nil
+}
+
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+}
+
+// execOn transcribes a query for db and executes it on ex, which is db or one of its transactions
+func execOn(ctx context.Context, db *sql.DB, ex execer, query Transcribeable) *Result {
 	q, args, err := query.Transcribe(db)
 	if err != nil {
 		panic(err)
 	}
 
 	writeLog(LogQueries, "EXEC: %s %+v", q, args)
-	result, err := db.Exec(q, args...)
+	start := time.Now()
+	result, err := ex.ExecContext(ctx, q, args...)
 	if err != nil {
-		m := fmt.Sprintf("FAILURE: %s in %s %+v", err, q, args)
-		writeLog(LogFailures, m)
-		panic(m)
+		recordStats(q, start, 0, err)
+		failStatement(err, q, args)
+	}
+
+	if statsEnabled() {
+		affected, _ := result.RowsAffected()
+		recordStats(q, start, affected, nil)
 	}
 
 	return &Result{result, q, args}
 }
 
+// statementError is the panic value of a failed statement, it wraps the error of the driver
+type statementError struct {
+	err   error
+	query string
+	args  []any
+}
+
+func (e *statementError) Error() string {
+	return fmt.Sprintf("FAILURE: %s in %s %+v", e.err, e.query, e.args)
+}
+
+func (e *statementError) Unwrap() error {
+	return e.err
+}
+
+func failStatement(err error, q string, args []any) {
+	e := &statementError{err, q, args}
+	writeLog(LogFailures, e.Error())
+	panic(e)
+}
+
 func As[T IEntity](rows *sql.Rows) *Rows[T] {
 	return &Rows[T]{Rows: rows}
 }
 
 type Rows[T IEntity] struct {
 	*sql.Rows
-	Query string
-	Args  []any
+	Query      string
+	Args       []any
+	rowCount   uint64
+	recordRows bool
+	plan       *rowsPlan
+	db         *sql.DB
+	with       []string
 }
 
 func (r *Rows[T]) Next() bool {
 	hasNext := r.Rows.Next()
 
-	if !hasNext {
-		_ = r.Rows.Close()
+	if hasNext {
+		r.rowCount++
+	} else {
+		r.Close()
 	}
 
 	return hasNext
 }
 
 func (r *Rows[T]) Current() T {
-	s, err := FromRows[T](r.Rows)
+	if r.plan == nil {
+		plan, err := newRowsPlan(getEntityMeta(typeOf[T]()), r.Rows)
+		if err != nil {
+			panic(err)
+		}
+		r.plan = plan
+	}
+
+	s, err := fromRowsPlan[T](r.Rows, r.plan)
 
 	if err != nil {
 		panic(err)
 	}
 
+	if r.db != nil {
+		err = callHook(newExecutor(context.Background(), r.db, r.db), &s, IAfterLoad.AfterLoad)
+		if err != nil {
+			panic(err)
+		}
+	}
+
 	return s
 }
 
@@ -99,7 +210,16 @@ func (r *Rows[T]) Row() (T, bool) {
 		return e, false
 	}
 
+	r.rowCount++
+
 	e := r.Current()
+
+	if len(r.with) > 0 {
+		s := []T{e}
+		r.loadWith(s)
+		e = s[0]
+	}
+
 	return e, true
 }
 
@@ -111,6 +231,8 @@ func (r *Rows[T]) Slice() []T {
 		s = append(s, r.Current())
 	}
 
+	r.loadWith(s)
+
 	return s
 }
 
@@ -132,6 +254,11 @@ func (r *Rows[T]) Count() uint {
 
 func (r *Rows[T]) Close() {
 	_ = r.Rows.Close()
+
+	if r.recordRows {
+		recordRows(r.Query, r.rowCount)
+		r.recordRows = false
+	}
 }
 
 type Result struct {
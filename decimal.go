@@ -0,0 +1,235 @@
+package db
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+var ErrInvalidDecimal = errors.New("invalid decimal")
+
+// Decimal is an exact decimal number, an unscaled integer and the number of digits after the point. It scans
+// DECIMAL columns without going through float64 and is passed to transcribers as its exact string. Decimals are
+// immutable, arithmetic returns new values and only Div and Rescale discard digits, rounding half away from zero
+type Decimal struct {
+	unscaled *big.Int
+	scale    int32
+}
+
+func NewDecimal(unscaled int64, scale int32) Decimal {
+	if scale < 0 {
+		panic("Decimal scale cannot be negative")
+	}
+	return Decimal{unscaled: big.NewInt(unscaled), scale: scale}
+}
+
+// ParseDecimal parses a plain decimal such as -12.3400, keeping its scale. Exponents are not accepted
+func ParseDecimal(s string) (Decimal, error) {
+	digits := s
+	if strings.HasPrefix(s, "-") || strings.HasPrefix(s, "+") {
+		digits = s[1:]
+	}
+	whole, frac, _ := strings.Cut(digits, ".")
+
+	if whole == "" && frac == "" {
+		return Decimal{}, fmt.Errorf("%w: %q", ErrInvalidDecimal, s)
+	}
+
+	for _, c := range whole + frac {
+		if c < '0' || c > '9' {
+			return Decimal{}, fmt.Errorf("%w: %q", ErrInvalidDecimal, s)
+		}
+	}
+
+	unscaled, _ := new(big.Int).SetString("0"+whole+frac, 10)
+	if strings.HasPrefix(s, "-") {
+		unscaled.Neg(unscaled)
+	}
+
+	return Decimal{unscaled: unscaled, scale: int32(len(frac))}, nil
+}
+
+func MustDecimal(s string) Decimal {
+	d, err := ParseDecimal(s)
+	if err != nil {
+		panic(err)
+	}
+	return d
+}
+
+func (d Decimal) int() *big.Int {
+	if d.unscaled == nil {
+		return new(big.Int)
+	}
+	return d.unscaled
+}
+
+func (d Decimal) Scale() int32 {
+	return d.scale
+}
+
+func (d Decimal) Sign() int {
+	return d.int().Sign()
+}
+
+func (d Decimal) IsZero() bool {
+	return d.Sign() == 0
+}
+
+func (d Decimal) Neg() Decimal {
+	return Decimal{unscaled: new(big.Int).Neg(d.int()), scale: d.scale}
+}
+
+// Rescale returns d with the given number of digits after the point, rounding when digits are dropped
+func (d Decimal) Rescale(scale int32) Decimal {
+	if scale < 0 {
+		panic("Decimal scale cannot be negative")
+	}
+
+	if scale >= d.scale {
+		return Decimal{unscaled: new(big.Int).Mul(d.int(), pow10(scale-d.scale)), scale: scale}
+	}
+
+	return Decimal{unscaled: divRound(d.int(), pow10(d.scale-scale)), scale: scale}
+}
+
+// Add returns d + o at the larger scale of both
+func (d Decimal) Add(o Decimal) Decimal {
+	a, b, scale := alignDecimals(d, o)
+	return Decimal{unscaled: new(big.Int).Add(a, b), scale: scale}
+}
+
+// Sub returns d - o at the larger scale of both
+func (d Decimal) Sub(o Decimal) Decimal {
+	a, b, scale := alignDecimals(d, o)
+	return Decimal{unscaled: new(big.Int).Sub(a, b), scale: scale}
+}
+
+// Mul returns the exact product, its scale is the sum of both scales
+func (d Decimal) Mul(o Decimal) Decimal {
+	return Decimal{unscaled: new(big.Int).Mul(d.int(), o.int()), scale: d.scale + o.scale}
+}
+
+// Div returns d / o rounded to the given scale
+func (d Decimal) Div(o Decimal, scale int32) Decimal {
+	if o.IsZero() {
+		panic("Decimal division by zero")
+	}
+	if scale < 0 {
+		panic("Decimal scale cannot be negative")
+	}
+
+	// d / o = (d.unscaled * 10^(scale + o.scale - d.scale)) / o.unscaled at the requested scale
+	num := new(big.Int).Set(d.int())
+	den := new(big.Int).Set(o.int())
+
+	if shift := scale + o.scale - d.scale; shift >= 0 {
+		num.Mul(num, pow10(shift))
+	} else {
+		den.Mul(den, pow10(-shift))
+	}
+
+	return Decimal{unscaled: divRound(num, den), scale: scale}
+}
+
+func (d Decimal) Cmp(o Decimal) int {
+	a, b, _ := alignDecimals(d, o)
+	return a.Cmp(b)
+}
+
+func (d Decimal) Equal(o Decimal) bool {
+	return d.Cmp(o) == 0
+}
+
+func (d Decimal) String() string {
+	s := new(big.Int).Abs(d.int()).String()
+
+	if d.scale > 0 {
+		if pad := int(d.scale) + 1 - len(s); pad > 0 {
+			s = strings.Repeat("0", pad) + s
+		}
+		s = s[:len(s)-int(d.scale)] + "." + s[len(s)-int(d.scale):]
+	}
+
+	if d.Sign() < 0 {
+		return "-" + s
+	}
+	return s
+}
+
+// Float64 returns the nearest float64, for display or statistics only
+func (d Decimal) Float64() float64 {
+	f, _ := strconv.ParseFloat(d.String(), 64)
+	return f
+}
+
+func (d Decimal) ValidateSQL() error {
+	return nil
+}
+
+func (d *Decimal) Scan(value any) error {
+	var err error
+
+	switch v := value.(type) {
+	case []byte:
+		*d, err = ParseDecimal(string(v))
+	case string:
+		*d, err = ParseDecimal(v)
+	case int64:
+		*d = NewDecimal(v, 0)
+	case uint64:
+		*d = Decimal{unscaled: new(big.Int).SetUint64(v)}
+	case float64:
+		// float columns are already inexact, this keeps the shortest representation of the stored value
+		*d, err = ParseDecimal(strconv.FormatFloat(v, 'f', -1, 64))
+	case nil:
+		err = fmt.Errorf("%w: cannot scan NULL, use Nullable[Decimal]", ErrInvalidDecimal)
+	default:
+		err = fmt.Errorf("%w: cannot scan %T", ErrInvalidDecimal, value)
+	}
+
+	return err
+}
+
+func (d Decimal) Value() (driver.Value, error) {
+	return d.String(), nil
+}
+
+func (d Decimal) MarshalText() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+func (d *Decimal) UnmarshalText(text []byte) error {
+	var err error
+	*d, err = ParseDecimal(string(text))
+	return err
+}
+
+func alignDecimals(a Decimal, b Decimal) (*big.Int, *big.Int, int32) {
+	if a.scale > b.scale {
+		return a.int(), b.Rescale(a.scale).int(), a.scale
+	}
+	return a.Rescale(b.scale).int(), b.int(), b.scale
+}
+
+func pow10(n int32) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(n)), nil)
+}
+
+// divRound divides rounding half away from zero
+func divRound(num *big.Int, den *big.Int) *big.Int {
+	q, r := new(big.Int).QuoRem(num, den, new(big.Int))
+
+	if new(big.Int).Mul(new(big.Int).Abs(r), big.NewInt(2)).Cmp(new(big.Int).Abs(den)) >= 0 {
+		if num.Sign()*den.Sign() < 0 {
+			q.Sub(q, big.NewInt(1))
+		} else {
+			q.Add(q, big.NewInt(1))
+		}
+	}
+
+	return q
+}
@@ -0,0 +1,141 @@
+package db
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestParseDecimal(t *testing.T) {
+	tests := map[string]string{
+		"0":                              "0",
+		"-12.3400":                       "-12.3400",
+		"+1.5":                           "1.5",
+		".05":                            "0.05",
+		"7.":                             "7",
+		"-0.001":                         "-0.001",
+		"1234567890123456789012345.6789": "1234567890123456789012345.6789",
+	}
+
+	for s, expected := range tests {
+		d, err := ParseDecimal(s)
+		if err != nil || d.String() != expected {
+			t.Errorf("%s: expected %s, got %s and %v", s, expected, d, err)
+		}
+	}
+
+	for _, s := range []string{"", "-", ".", "1e5", "1.2.3", "abc", "1,5", "-+1", "+-1", "--1", "++1"} {
+		if _, err := ParseDecimal(s); !errors.Is(err, ErrInvalidDecimal) {
+			t.Errorf("%q: expected an invalid decimal, got %v", s, err)
+		}
+	}
+}
+
+func TestDecimalArithmetic(t *testing.T) {
+	a := MustDecimal("0.1")
+	b := MustDecimal("0.20")
+
+	if s := a.Add(b).String(); s != "0.30" {
+		t.Errorf("0.1 + 0.20: expected 0.30, got %s", s)
+	}
+
+	if s := a.Sub(b).String(); s != "-0.10" {
+		t.Errorf("0.1 - 0.20: expected -0.10, got %s", s)
+	}
+
+	if s := MustDecimal("19.99").Mul(MustDecimal("3")).String(); s != "59.97" {
+		t.Errorf("19.99 * 3: expected 59.97, got %s", s)
+	}
+
+	if s := MustDecimal("10").Div(MustDecimal("3"), 4).String(); s != "3.3333" {
+		t.Errorf("10 / 3: expected 3.3333, got %s", s)
+	}
+
+	if s := MustDecimal("-2").Div(MustDecimal("3"), 2).String(); s != "-0.67" {
+		t.Errorf("-2 / 3: expected -0.67, got %s", s)
+	}
+
+	if s := MustDecimal("1.005").Rescale(2).String(); s != "1.01" {
+		t.Errorf("1.005 at scale 2: expected 1.01, got %s", s)
+	}
+
+	if s := MustDecimal("-1.005").Rescale(2).String(); s != "-1.01" {
+		t.Errorf("-1.005 at scale 2: expected -1.01, got %s", s)
+	}
+
+	if s := MustDecimal("1.5").Rescale(3).String(); s != "1.500" {
+		t.Errorf("1.5 at scale 3: expected 1.500, got %s", s)
+	}
+
+	if !a.Equal(MustDecimal("0.100")) || a.Cmp(b) != -1 || !(Decimal{}).IsZero() {
+		t.Error("unexpected comparison")
+	}
+
+	if a.String() != "0.1" {
+		t.Error("arithmetic should not modify its operands")
+	}
+}
+
+func TestDecimalScanValue(t *testing.T) {
+	var d Decimal
+
+	for value, expected := range map[any]string{
+		"12345678901234567890.0123456789": "12345678901234567890.0123456789",
+		int64(-42):                        "-42",
+		uint64(math.MaxUint64):            "18446744073709551615",
+		0.1:                               "0.1",
+	} {
+		if err := d.Scan(value); err != nil || d.String() != expected {
+			t.Errorf("%v: expected %s, got %s and %v", value, expected, d, err)
+		}
+	}
+
+	if err := d.Scan([]byte("99.9900")); err != nil || d.String() != "99.9900" {
+		t.Errorf("expected bytes to be scanned exactly, got %s and %v", d, err)
+	}
+
+	if v, err := d.Value(); err != nil || v != "99.9900" {
+		t.Errorf("expected the exact string, got %v and %v", v, err)
+	}
+
+	if err := d.Scan(nil); !errors.Is(err, ErrInvalidDecimal) {
+		t.Errorf("expected NULL to be rejected, got %v", err)
+	}
+
+	var n Nullable[Decimal]
+	if err := n.Scan([]byte("1.10")); err != nil || !n.Valid || n.Wrapped.String() != "1.10" {
+		t.Errorf("expected a nullable decimal, got %s and %v", n.Wrapped, err)
+	}
+}
+
+func TestTranscribeDecimal(t *testing.T) {
+	q := NewQuery().
+		Select("*").
+		From("ledger").
+		WhereEq("ledger_amount", MustDecimal("0.1000000000000000000001"))
+
+	s, args, err := (&MySQLTranscriber{}).Transcribe(q)
+	if err != nil || s != "SELECT * FROM ledger WHERE ledger_amount = 0.1000000000000000000001" || len(args) != 0 {
+		t.Errorf("unexpected query %s %v %v", s, args, err)
+	}
+
+	_, args, err = (&MySQLTranscriber{UsePlaceholders: true}).Transcribe(q)
+	if err != nil || len(args) != 1 || args[0] != "0.1000000000000000000001" {
+		t.Errorf("expected the exact string as argument, got %v and %v", args, err)
+	}
+
+	encoded, err := json.Marshal(q)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	decoded, err := QueryDecoder{}.DecodeQuery(encoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if s2, _, _ := (&MySQLTranscriber{}).Transcribe(decoded); s2 != s {
+		t.Errorf("expected decimals to survive encoding, got %s", s2)
+	}
+}
@@ -0,0 +1,172 @@
+package db
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"reflect"
+	"strconv"
+	"time"
+)
+
+type Change struct {
+	Old any
+	New any
+}
+
+// Changes diffs the mapped columns of an entity against the snapshot its embedded Entity took when it was hydrated.
+// Entities without a snapshot report every non-zero column, which is what UpdateRow writes for them. Values that
+// cannot be compared exactly, such as reformatted JSON, are reported as changed
+func Changes[T IEntity](entity T) map[string]Change {
+	changes, _ := entityChanges(&entity)
+	return changes
+}
+
+// IsChanged reports whether any of the given columns changed, or any column at all when none are given
+func IsChanged[T IEntity](entity T, columns ...string) bool {
+	changes := Changes(entity)
+
+	if len(columns) == 0 {
+		return len(changes) > 0
+	}
+
+	for _, c := range columns {
+		if _, ok := changes[c]; ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+func entityChanges[T IEntity](entity *T) (map[string]Change, bool) {
+	snapshot := (*entity).entityFields()
+	current := entityToMap(entity, false, false, false)
+	changes := make(map[string]Change)
+
+	decimals := make(map[string]bool)
+	for _, f := range getEntityMeta(typeOf[T]()).Fields {
+		if f.Field.Type == typeOf[Decimal]() || f.Field.Type == typeOf[Nullable[Decimal]]() {
+			decimals[f.Column] = true
+		}
+	}
+
+	for k, v := range current {
+		old, has := snapshot[k]
+
+		if !has {
+			if v != nil && !reflect.ValueOf(v).IsZero() {
+				changes[k] = Change{New: v}
+			}
+		} else if !sameValue(*old.Value, v, decimals[k] || isDecimalColumn(old.Type)) {
+			changes[k] = Change{Old: *old.Value, New: v}
+		}
+	}
+
+	return changes, len(snapshot) > 0
+}
+
+// changedFields returns the columns UpdateRow should write for a tracked entity, always including the primary key columns
+func changedFields[T IEntity](entity *T) (map[string]any, bool) {
+	changes, tracked := entityChanges(entity)
+	if !tracked {
+		return nil, false
+	}
+
+	fields := make(map[string]any)
+	for k, c := range changes {
+		fields[k] = c.New
+	}
+
+	current := entityToMap(entity, false, false, false)
+	for _, pk := range getEntityMeta(typeOf[T]()).PrimaryKeys {
+		if v, ok := current[pk.Column]; ok {
+			fields[pk.Column] = v
+		}
+	}
+
+	return fields, true
+}
+
+// commitChanges updates the snapshot with the values that were written, so the entity is clean again
+func commitChanges[T IEntity](entity *T, fields map[string]any) {
+	snapshot := (*entity).entityFields()
+	if snapshot == nil {
+		return
+	}
+
+	for k, v := range fields {
+		value := v
+		snapshot[k] = field{
+			Type:  snapshot[k].Type,
+			Value: &value,
+		}
+	}
+}
+
+// sameValue compares a scanned value with the value that would be written in its place. Numbers are compared by
+// value when the column or the field is a decimal or the written value is a float, so 12.50 equals 12.5
+func sameValue(scanned any, v any, decimal bool) bool {
+	a, b := comparableValue(scanned), comparableValue(v)
+	if a == b {
+		return true
+	}
+
+	as, aok := a.(string)
+	bs, bok := b.(string)
+	if !aok || !bok {
+		return false
+	}
+
+	if decimal {
+		ad, aerr := ParseDecimal(as)
+		bd, berr := ParseDecimal(bs)
+		return aerr == nil && berr == nil && ad.Equal(bd)
+	}
+
+	if isFloatValue(v) {
+		af, aerr := strconv.ParseFloat(as, 64)
+		bf, berr := strconv.ParseFloat(bs, 64)
+		return aerr == nil && berr == nil && af == bf
+	}
+
+	return false
+}
+
+// comparableValue formats a value the way the driver writes it, bools as the 1 and 0 MySQL stores
+func comparableValue(v any) any {
+	if v == nil {
+		return nil
+	}
+
+	dv, err := driver.DefaultParameterConverter.ConvertValue(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+
+	switch x := dv.(type) {
+	case nil:
+		return nil
+	case []byte:
+		return string(x)
+	case bool:
+		if x {
+			return "1"
+		}
+		return "0"
+	case time.Time:
+		return x.UTC().Format(time.RFC3339Nano)
+	default:
+		return fmt.Sprint(x)
+	}
+}
+
+func isDecimalColumn(ct *sql.ColumnType) bool {
+	return ct != nil && (ct.DatabaseTypeName() == "DECIMAL" || ct.DatabaseTypeName() == "NUMERIC")
+}
+
+func isFloatValue(v any) bool {
+	dv, err := driver.DefaultParameterConverter.ConvertValue(v)
+	_, ok := dv.(float64)
+	return err == nil && ok
+}
@@ -0,0 +1,111 @@
+package db
+
+import (
+	"context"
+	"testing"
+)
+
+func TestChanges(t *testing.T) {
+	c, err := FromMap[Child](map[string]any{
+		"child_id":   int64(1),
+		"child_name": "Child 1",
+		"parent_id":  "2",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if IsChanged(c) {
+		t.Errorf("a freshly loaded entity should not be changed: %v", Changes(c))
+	}
+
+	c.Name = ""
+	changes := Changes(c)
+
+	if len(changes) != 1 || changes["child_name"].Old != "Child 1" || changes["child_name"].New != "" {
+		t.Errorf("expected child_name to be set to zero, got %v", changes)
+	}
+
+	if !IsChanged(c, "child_name") || IsChanged(c, "parent_id") {
+		t.Error("unexpected change set")
+	}
+
+	fields, err := doFilterUpdate(&c)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(fields) != 2 || fields["child_name"] != "" || fields["child_id"] != int64(1) {
+		t.Errorf("expected the changed column and primary key, got %v", fields)
+	}
+
+	commitChanges(&c, fields)
+
+	if IsChanged(c) {
+		t.Errorf("committed changes should clear the change set: %v", Changes(c))
+	}
+}
+
+func TestChanges_Nullable(t *testing.T) {
+	p, err := FromMap[Parent](map[string]any{
+		"parent_id":   int64(1),
+		"parent_name": "Name",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p.Name = Nullable[string]{}
+	changes := Changes(p)
+
+	if c, ok := changes["parent_name"]; !ok || c.New != nil {
+		t.Errorf("expected parent_name to be set to NULL, got %v", changes)
+	}
+}
+
+func TestChanges_Untracked(t *testing.T) {
+	c := Child{ID: 1, Name: "Name"}
+	changes := Changes(c)
+
+	if len(changes) != 2 || changes["child_name"].New != "Name" {
+		t.Errorf("untracked entities should report non-zero columns, got %v", changes)
+	}
+}
+
+type dirtyEntity struct {
+	*Entity
+	ID     int64   `field:"dirty_id" primary:"dirty"`
+	Active bool    `field:"dirty_active"`
+	Price  float64 `field:"dirty_price"`
+	Amount Decimal `field:"dirty_amount"`
+}
+
+func TestChanges_Normalized(t *testing.T) {
+	e, err := FromMap[dirtyEntity](map[string]any{
+		"dirty_id":     int64(1),
+		"dirty_active": int64(1),
+		"dirty_price":  "12.50",
+		"dirty_amount": "3.10",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	e.Amount = MustDecimal("3.1")
+
+	if IsChanged(e) {
+		t.Errorf("equal bools, floats and decimals should not be changed: %v", Changes(e))
+	}
+
+	u, err := newRowUpdate(newExecutor(context.Background(), nil, nil), &e, nil)
+	if err != nil || u != nil {
+		t.Errorf("expected no update for an unchanged entity, got %v, %v", u, err)
+	}
+
+	e.Active = false
+	e.Price = 12.51
+
+	if changes := Changes(e); len(changes) != 2 || changes["dirty_active"].New != false {
+		t.Errorf("expected dirty_active and dirty_price to change, got %v", changes)
+	}
+}
@@ -0,0 +1,250 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"reflect"
+	"strings"
+)
+
+// With eager loads the relations to the given tables when the rows are read with Slice or Row, see Preload
+func (r *Rows[T]) With(tables ...string) *Rows[T] {
+	if r.db == nil {
+		panic("With requires rows returned by Query or GetRows")
+	}
+
+	r.with = append(r.with, tables...)
+	return r
+}
+
+func (r *Rows[T]) loadWith(entities []T) {
+	Preload(r.db, entities, r.with...)
+}
+
+// Preload loads the relations to the given tables for all entities with one IN query per relation. OneToMany and
+// ManyToMany children are assigned to the slice field tagged relation:"<table>", the first slice field whose element
+// type is stored in that table or the HasMany field of that type. ManyToOne parents are assigned to BelongsTo fields
+func Preload[T IEntity](db *sql.DB, entities []T, tables ...string) {
+	for _, table := range tables {
+		eagerLoad(db, entities, table)
+	}
+}
+
+func eagerLoad[T IEntity](db *sql.DB, entities []T, table string) {
+	if len(entities) == 0 {
+		return
+	}
+
+	var p T
+	pt := mustGetTable(&p)
+
+	relation := getRelation(db, pt, table)
+	if relation == nil {
+		panic("Invalid relation " + table + " -> " + pt)
+	}
+
+	values := reflect.ValueOf(entities)
+	fieldIndex, sliceType, handle := mustGetRelationField(typeOf[T](), table)
+	elemType := sliceType.Elem()
+	if elemType.Kind() == reflect.Pointer {
+		elemType = elemType.Elem()
+	}
+
+	if _, ok := relation.(ManyToOneDef); ok {
+		if !handle {
+			panic("ManyToOne " + pt + " -> " + table + " relation can only be preloaded into a BelongsTo field")
+		}
+		loadBelongsTo(db, values, fieldIndex, elemType)
+		return
+	}
+
+	keyed, ok := relation.(interface{ parentKey() string })
+	if !ok {
+		panic("Invalid relation " + table + " -> " + pt)
+	}
+
+	// children refer to the relation's parent key column, which is not necessarily the primary key
+	column := unqualified(keyed.parentKey())
+	keys := make([]string, len(entities))
+	ids := make([]any, 0, len(entities))
+	seen := make(map[string]bool)
+
+	for i := range entities {
+		id, has := getField(values.Index(i).Addr(), column, false, false)
+		if !has {
+			panic("No value of " + pt + " key column " + column + " provided")
+		}
+
+		keys[i] = asString(id.Elem().Interface())
+		if !seen[keys[i]] {
+			seen[keys[i]] = true
+			ids = append(ids, id.Elem().Interface())
+		}
+	}
+
+	q := NewQuery().
+		Select(TableField(table, "*")).
+		From(table).
+		ComposeWith(relation.getChildrenInQuery(ids))
+
+	joinParents(db, q, elemType, table, pt)
+	selectComputed(q, elemType)
+	scopeTrashed(q, elemType, table)
+
+	rows := queryStd(db, q)
+	defer func() { _ = rows.Close() }()
+
+	plan, err := newRowsPlan(getEntityMeta(elemType), rows)
+	if err != nil {
+		panic(err)
+	}
+
+	parentKey := relation.childrenParentKey()
+	parentKey = parentKey[strings.LastIndex(parentKey, ".")+1:]
+	children := make(map[string][]reflect.Value)
+
+	for rows.Next() {
+		child := reflect.New(elemType)
+		err := scanRow(rows, plan, child.Interface())
+		if err != nil {
+			panic(err)
+		}
+
+		e, ok := child.Interface().(IEntity)
+		if !ok {
+			panic(elemType.String() + " must embed *Entity to be eager loaded")
+		}
+
+		err = callHook(newExecutor(context.Background(), db, db), child.Interface(), IAfterLoad.AfterLoad)
+		if err != nil {
+			panic(err)
+		}
+
+		f, ok := e.entityFields()[parentKey]
+		if !ok {
+			panic("Eager loaded " + table + " rows do not have column " + parentKey)
+		}
+
+		key := asString(*f.Value)
+		if sliceType.Elem().Kind() == reflect.Pointer {
+			children[key] = append(children[key], child)
+		} else {
+			children[key] = append(children[key], child.Elem())
+		}
+	}
+
+	for i, key := range keys {
+		s := reflect.MakeSlice(sliceType, 0, len(children[key]))
+		s = reflect.Append(s, children[key]...)
+
+		if handle {
+			values.Index(i).FieldByIndex(fieldIndex).Addr().Interface().(relationPreloader).preload(s)
+		} else {
+			values.Index(i).FieldByIndex(fieldIndex).Set(s)
+		}
+	}
+}
+
+func loadBelongsTo(db *sql.DB, values reflect.Value, fieldIndex []int, elemType reflect.Type) {
+	table := mustGetTable(reflect.New(elemType).Interface())
+	pkName := mustGetPrimaryKeyFieldName(reflect.New(elemType).Interface())
+
+	ids := make([]any, 0, values.Len())
+	seen := make(map[string]bool)
+
+	for i := 0; i < values.Len(); i++ {
+		key, _ := values.Index(i).FieldByIndex(fieldIndex).Interface().(driver.Valuer).Value()
+		if key != nil && !seen[asString(key)] {
+			seen[asString(key)] = true
+			ids = append(ids, key)
+		}
+	}
+
+	parents := make(map[string]reflect.Value)
+
+	if len(ids) > 0 {
+		q := NewQuery().
+			Select(TableField(table, "*")).
+			From(table).
+			WhereIn(TableField(table, pkName), ids)
+
+		joinParents(db, q, elemType, table, "")
+		selectComputed(q, elemType)
+		scopeTrashed(q, elemType, table)
+
+		rows := queryStd(db, q)
+		defer func() { _ = rows.Close() }()
+
+		plan, err := newRowsPlan(getEntityMeta(elemType), rows)
+		if err != nil {
+			panic(err)
+		}
+
+		for rows.Next() {
+			parent := reflect.New(elemType)
+			err := scanRow(rows, plan, parent.Interface())
+			if err != nil {
+				panic(err)
+			}
+
+			err = callHook(newExecutor(context.Background(), db, db), parent.Interface(), IAfterLoad.AfterLoad)
+			if err != nil {
+				panic(err)
+			}
+
+			pk, _ := getField(parent, pkName, false, false)
+			parents[asString(pk.Elem().Interface())] = parent
+		}
+	}
+
+	for i := 0; i < values.Len(); i++ {
+		field := values.Index(i).FieldByIndex(fieldIndex)
+		key, _ := field.Interface().(driver.Valuer).Value()
+
+		parent, ok := parents[asString(key)]
+		if key == nil || !ok {
+			parent = reflect.Zero(reflect.PointerTo(elemType))
+		}
+
+		field.Addr().Interface().(relationPreloader).preload(parent)
+	}
+}
+
+// mustGetRelationField finds the slice or relation handle field that holds the entities of a related table,
+// the returned type is the slice type of the field's entities
+func mustGetRelationField(t reflect.Type, table string) ([]int, reflect.Type, bool) {
+	meta := getEntityMeta(t)
+
+	for _, f := range meta.Fields {
+		if f.Field.Type.Kind() == reflect.Slice && f.Field.Tag.Get("relation") == table {
+			return []int{f.Index}, f.Field.Type, false
+		}
+	}
+
+	for _, f := range meta.Fields {
+		if p, ok := reflect.New(f.Field.Type).Interface().(relationPreloader); ok {
+			if et, ok := getTable(reflect.New(p.relatedType()).Interface()); ok && et == table {
+				return []int{f.Index}, reflect.SliceOf(p.relatedType()), true
+			}
+			continue
+		}
+
+		if f.Field.Type.Kind() != reflect.Slice || f.Field.Tag.Get("relation") != "" {
+			continue
+		}
+
+		elem := f.Field.Type.Elem()
+		if elem.Kind() == reflect.Pointer {
+			elem = elem.Elem()
+		}
+
+		if elem.Kind() == reflect.Struct {
+			if et, ok := getTable(reflect.New(elem).Interface()); ok && et == table {
+				return []int{f.Index}, f.Field.Type, false
+			}
+		}
+	}
+
+	panic(t.String() + " does not have a field for relation " + table)
+}
@@ -0,0 +1,43 @@
+package db
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRelationField(t *testing.T) {
+	index, sliceType, _ := mustGetRelationField(typeOf[ParentWithRelations](), "friends")
+	if !reflect.DeepEqual(index, []int{4}) || sliceType != typeOf[[]*Friend]() {
+		t.Errorf("unexpected friends field %v %v", index, sliceType)
+	}
+
+	index, _, _ = mustGetRelationField(typeOf[ParentWithRelations](), "children")
+	if !reflect.DeepEqual(index, []int{3}) {
+		t.Errorf("unexpected children field %v", index)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("expected a panic for a missing relation field")
+		}
+	}()
+	mustGetRelationField(typeOf[ParentWithRelations](), "parents")
+}
+
+func TestTranscribeChildrenInQuery(t *testing.T) {
+	r := OneToManyDef{"parents", "parents.parent_id", "children", "children.parent_id"}
+	q := NewQuery().
+		Select(TableField("children", "*")).
+		From("children").
+		ComposeWith(r.getChildrenInQuery([]any{1, 2}))
+
+	actual, _, err := (&MySQLTranscriber{}).Transcribe(q)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "SELECT children.*, parents.* FROM children LEFT JOIN parents ON parents.parent_id = children.parent_id WHERE children.parent_id IN(1, 2)"
+	if actual != expected {
+		t.Errorf("expected %s, got %s", expected, actual)
+	}
+}
@@ -0,0 +1,266 @@
+package db
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+)
+
+var (
+	ErrNoKeyProvider = errors.New("no key provider")
+	ErrUnknownKey    = errors.New("unknown encryption key")
+	ErrDecrypt       = errors.New("cannot decrypt value")
+)
+
+// KeyProvider supplies the AES-128, AES-192 or AES-256 keys of Encrypted fields. Values are encrypted with the
+// current key and decrypted with the key whose ID they carry, so older keys must be kept until rows are rewritten
+type KeyProvider interface {
+	CurrentKey() (id string, key []byte, err error)
+	Key(id string) ([]byte, error)
+}
+
+// StaticKeys is a KeyProvider holding its keys in memory
+type StaticKeys struct {
+	Current string
+	Keys    map[string][]byte
+}
+
+func (k StaticKeys) CurrentKey() (string, []byte, error) {
+	key, err := k.Key(k.Current)
+	return k.Current, key, err
+}
+
+func (k StaticKeys) Key(id string) ([]byte, error) {
+	key, ok := k.Keys[id]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownKey, id)
+	}
+	return key, nil
+}
+
+var keyProvider KeyProvider
+
+func SetKeyProvider(p KeyProvider) {
+	keyProvider = p
+}
+
+const (
+	encryptedVersion       = byte(1)
+	encryptedRandomized    = byte(0)
+	encryptedDeterministic = byte(1)
+)
+
+// Encrypted stores its JSON encoded value with AES-GCM and a random nonce, prefixed with the ID of the key that
+// encrypted it. Values are written with the current key, so rows are rotated to it when they are next updated.
+// The plaintext is only available through Get, printing or logging the value shows [encrypted]
+type Encrypted[T any] struct {
+	plain         T
+	valid         bool
+	deterministic bool
+	keyId         string
+	encoded       []byte
+	ciphertext    []byte
+	scannedMode   byte
+}
+
+func NewEncrypted[T any](v T) Encrypted[T] {
+	return Encrypted[T]{plain: v, valid: true}
+}
+
+// Deterministic is an Encrypted value whose nonce is derived from the plaintext, so equal values have equal
+// ciphertexts under the same key and can be looked up with WhereEq. The mode is part of the field type, so values
+// scanned from NULL or from randomized ciphertexts are written deterministically too
+type Deterministic[T any] struct {
+	Encrypted[T]
+}
+
+func NewDeterministic[T any](v T) Deterministic[T] {
+	return Deterministic[T]{NewEncrypted(v)}
+}
+
+func (d Deterministic[T]) Value() (driver.Value, error) {
+	d.deterministic = true
+	return d.Encrypted.Value()
+}
+
+func (e Encrypted[T]) Get() (T, bool) {
+	return e.plain, e.valid
+}
+
+func (e *Encrypted[T]) Set(v T) {
+	e.plain = v
+	e.valid = true
+}
+
+// KeyID returns the ID of the key a scanned value was encrypted with
+func (e Encrypted[T]) KeyID() string {
+	return e.keyId
+}
+
+func (e *Encrypted[T]) Scan(value any) error {
+	*e = Encrypted[T]{}
+
+	if value == nil {
+		return nil
+	}
+
+	var data []byte
+	switch v := value.(type) {
+	case []byte:
+		data = bytes.Clone(v)
+	case string:
+		data = []byte(v)
+	default:
+		return fmt.Errorf("%w: cannot scan %T", ErrDecrypt, value)
+	}
+
+	mode, keyId, encoded, err := decrypt(data)
+	if err != nil {
+		return err
+	}
+
+	if err = json.Unmarshal(encoded, &e.plain); err != nil {
+		return fmt.Errorf("%w: %v", ErrDecrypt, err)
+	}
+
+	e.valid = true
+	e.scannedMode = mode
+	e.keyId = keyId
+	e.encoded = encoded
+	e.ciphertext = data
+	return nil
+}
+
+// Value reuses the scanned ciphertext while the value, the current key and the mode are unchanged, so unchanged
+// fields are not reported as changed
+func (e Encrypted[T]) Value() (driver.Value, error) {
+	if !e.valid {
+		return nil, nil
+	}
+
+	encoded, err := json.Marshal(e.plain)
+	if err != nil {
+		return nil, err
+	}
+
+	if keyProvider == nil {
+		return nil, ErrNoKeyProvider
+	}
+
+	keyId, key, err := keyProvider.CurrentKey()
+	if err != nil {
+		return nil, err
+	}
+
+	mode := encryptedRandomized
+	if e.deterministic {
+		mode = encryptedDeterministic
+	}
+
+	if e.ciphertext != nil && keyId == e.keyId && mode == e.scannedMode && bytes.Equal(encoded, e.encoded) {
+		return e.ciphertext, nil
+	}
+
+	return encrypt(keyId, key, encoded, e.deterministic)
+}
+
+func (e Encrypted[T]) String() string {
+	return "[encrypted]"
+}
+
+func (e Encrypted[T]) GoString() string {
+	return "[encrypted]"
+}
+
+func (e Encrypted[T]) LogValue() slog.Value {
+	return slog.StringValue("[encrypted]")
+}
+
+func encrypt(keyId string, key []byte, plaintext []byte, deterministic bool) ([]byte, error) {
+	if len(keyId) > 255 {
+		return nil, fmt.Errorf("%w: key ID %q is too long", ErrUnknownKey, keyId)
+	}
+
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	mode := encryptedRandomized
+	nonce := make([]byte, gcm.NonceSize())
+
+	if deterministic {
+		mode = encryptedDeterministic
+		copy(nonce, syntheticNonce(key, plaintext))
+	} else if _, err = rand.Read(nonce); err != nil {
+		return nil, err
+	}
+
+	header := append([]byte{encryptedVersion, mode, byte(len(keyId))}, keyId...)
+	out := append(bytes.Clone(header), nonce...)
+
+	return gcm.Seal(out, nonce, plaintext, header), nil
+}
+
+func decrypt(data []byte) (byte, string, []byte, error) {
+	if len(data) < 3 || data[0] != encryptedVersion || len(data) < 3+int(data[2]) {
+		return 0, "", nil, fmt.Errorf("%w: invalid header", ErrDecrypt)
+	}
+
+	mode := data[1]
+	header := data[:3+int(data[2])]
+	keyId := string(header[3:])
+
+	if keyProvider == nil {
+		return 0, "", nil, ErrNoKeyProvider
+	}
+
+	key, err := keyProvider.Key(keyId)
+	if err != nil {
+		return 0, "", nil, err
+	}
+
+	gcm, err := newGCM(key)
+	if err != nil {
+		return 0, "", nil, err
+	}
+
+	rest := data[len(header):]
+	if len(rest) < gcm.NonceSize() {
+		return 0, "", nil, fmt.Errorf("%w: missing nonce", ErrDecrypt)
+	}
+
+	plaintext, err := gcm.Open(nil, rest[:gcm.NonceSize()], rest[gcm.NonceSize():], header)
+	if err != nil {
+		return 0, "", nil, fmt.Errorf("%w: %v", ErrDecrypt, err)
+	}
+
+	return mode, keyId, plaintext, nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// syntheticNonce derives the nonce of a deterministic value from the plaintext, with a MAC key derived from the
+// encryption key so the key itself is only used by AES
+func syntheticNonce(key []byte, plaintext []byte) []byte {
+	derive := hmac.New(sha256.New, key)
+	derive.Write([]byte("deterministic nonce"))
+
+	mac := hmac.New(sha256.New, derive.Sum(nil))
+	mac.Write(plaintext)
+	return mac.Sum(nil)
+}
@@ -0,0 +1,178 @@
+package db
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestEncrypted(t *testing.T) {
+	SetKeyProvider(StaticKeys{Current: "k1", Keys: map[string][]byte{"k1": make([]byte, 32)}})
+	defer SetKeyProvider(nil)
+
+	e := NewEncrypted(map[string]string{"email": "jane@example.com"})
+
+	v, err := e.Value()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if bytes.Contains(v.([]byte), []byte("jane")) {
+		t.Error("the plaintext should not be in the ciphertext")
+	}
+
+	if other, _ := e.Value(); bytes.Equal(other.([]byte), v.([]byte)) {
+		t.Error("randomized values should have different ciphertexts")
+	}
+
+	var scanned Encrypted[map[string]string]
+	if err = scanned.Scan(v); err != nil {
+		t.Fatal(err)
+	}
+
+	if plain, valid := scanned.Get(); !valid || plain["email"] != "jane@example.com" || scanned.KeyID() != "k1" {
+		t.Errorf("unexpected decrypted value %v with key %s", plain, scanned.KeyID())
+	}
+
+	if again, _ := scanned.Value(); !bytes.Equal(again.([]byte), v.([]byte)) {
+		t.Error("unchanged scanned values should keep their ciphertext")
+	}
+
+	tampered := bytes.Clone(v.([]byte))
+	tampered[len(tampered)-1] ^= 1
+	if err = scanned.Scan(tampered); !errors.Is(err, ErrDecrypt) {
+		t.Errorf("expected tampered values to be rejected, got %v", err)
+	}
+
+	if err = scanned.Scan(nil); err != nil {
+		t.Fatal(err)
+	}
+
+	if v, err = scanned.Value(); v != nil || err != nil {
+		t.Errorf("expected NULL to be kept, got %v and %v", v, err)
+	}
+}
+
+func TestEncryptedDeterministic(t *testing.T) {
+	SetKeyProvider(StaticKeys{Current: "k1", Keys: map[string][]byte{"k1": make([]byte, 16)}})
+	defer SetKeyProvider(nil)
+
+	a, _ := NewDeterministic("lookup").Value()
+	b, _ := NewDeterministic("lookup").Value()
+	c, _ := NewDeterministic("other").Value()
+
+	if !bytes.Equal(a.([]byte), b.([]byte)) || bytes.Equal(a.([]byte), c.([]byte)) {
+		t.Error("deterministic values should only have equal ciphertexts for equal plaintexts")
+	}
+
+	var scanned Deterministic[string]
+	if err := scanned.Scan(a); err != nil {
+		t.Fatal(err)
+	}
+
+	scanned.Set("changed")
+	if v, _ := scanned.Value(); !bytes.Equal(v.([]byte), mustValue(t, NewDeterministic("changed"))) {
+		t.Error("set values should keep the deterministic mode")
+	}
+
+	var null Deterministic[string]
+	if err := null.Scan(nil); err != nil {
+		t.Fatal(err)
+	}
+
+	null.Set("lookup")
+	if v, _ := null.Value(); !bytes.Equal(v.([]byte), a.([]byte)) {
+		t.Error("values set after scanning NULL should be found by their deterministic ciphertext")
+	}
+
+	randomized := mustValue(t, NewEncrypted("lookup"))
+	if err := scanned.Scan(randomized); err != nil {
+		t.Fatal(err)
+	}
+
+	if v, _ := scanned.Value(); !bytes.Equal(v.([]byte), a.([]byte)) {
+		t.Error("randomized ciphertexts should be rewritten deterministically")
+	}
+
+	var plain Encrypted[string]
+	if err := plain.Scan(a); err != nil {
+		t.Fatal(err)
+	}
+
+	plain.Set("changed")
+	if v, _ := plain.Value(); bytes.Equal(v.([]byte), mustValue(t, NewDeterministic("changed"))) {
+		t.Error("Encrypted values should always be written randomized")
+	}
+}
+
+func TestEncryptedRotation(t *testing.T) {
+	old := bytes.Repeat([]byte{1}, 32)
+	SetKeyProvider(StaticKeys{Current: "old", Keys: map[string][]byte{"old": old}})
+	defer SetKeyProvider(nil)
+
+	v := mustValue(t, NewEncrypted(42))
+
+	SetKeyProvider(StaticKeys{Current: "new", Keys: map[string][]byte{"old": old, "new": make([]byte, 32)}})
+
+	var scanned Encrypted[int]
+	if err := scanned.Scan(v); err != nil || scanned.KeyID() != "old" {
+		t.Fatalf("expected values of the old key to be decrypted, got %v", err)
+	}
+
+	rotated := mustValue(t, scanned)
+	if err := scanned.Scan(rotated); err != nil || scanned.KeyID() != "new" {
+		t.Errorf("expected values to be written with the current key, got %s and %v", scanned.KeyID(), err)
+	}
+
+	SetKeyProvider(StaticKeys{Current: "new", Keys: map[string][]byte{"new": make([]byte, 32)}})
+	if err := scanned.Scan(v); !errors.Is(err, ErrUnknownKey) {
+		t.Errorf("expected removed keys to be unknown, got %v", err)
+	}
+
+	SetKeyProvider(nil)
+	if _, err := NewEncrypted(1).Value(); !errors.Is(err, ErrNoKeyProvider) {
+		t.Errorf("expected an error without a key provider, got %v", err)
+	}
+}
+
+func TestEncryptedWrite_NoKeyProvider(t *testing.T) {
+	token := SecretToken{Name: "card", Secret: NewDeterministic("4111 1111 1111 1111")}
+
+	if _, err := doFilterInsert(&token); !errors.Is(err, ErrNoKeyProvider) {
+		t.Errorf("expected inserts to fail without a key provider, got %v", err)
+	}
+
+	if _, err := doFilterUpdate(&token); !errors.Is(err, ErrNoKeyProvider) {
+		t.Errorf("expected updates to fail without a key provider, got %v", err)
+	}
+
+	token.Secret = Deterministic[string]{}
+	if _, err := doFilterInsert(&token); err != nil {
+		t.Errorf("unset secrets should not need a key provider, got %v", err)
+	}
+}
+
+func TestEncryptedRedaction(t *testing.T) {
+	e := SecretToken{Name: "card", Secret: NewDeterministic("4111 1111 1111 1111")}
+
+	var logged strings.Builder
+	slog.New(slog.NewTextHandler(&logged, nil)).Info("token", "secret", e.Secret)
+
+	for _, s := range []string{fmt.Sprint(e), fmt.Sprintf("%+v", e), fmt.Sprintf("%#v", e), fmt.Sprintf("%v", []any{e.Secret}), logged.String()} {
+		if strings.Contains(s, "4111") {
+			t.Errorf("the plaintext should never be printed: %s", s)
+		}
+	}
+}
+
+func mustValue(t *testing.T, e driver.Valuer) []byte {
+	v, err := e.Value()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return v.([]byte)
+}
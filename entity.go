@@ -6,123 +6,206 @@ import (
 	"errors"
 	"fmt"
 	"reflect"
+	"strings"
+	"time"
 )
 
 func FromRows[T any](rows *sql.Rows) (T, error) {
+	plan, err := newRowsPlan(getEntityMeta(typeOf[T]()), rows)
+	if err != nil {
+		var e T
+		return e, err
+	}
+
+	return fromRowsPlan[T](rows, plan)
+}
+
+func fromRowsPlan[T any](rows *sql.Rows, plan *rowsPlan) (T, error) {
 	e := new(T)
+	err := scanRow(rows, plan, e)
+	return *e, err
+}
 
-	entityField := reflect.ValueOf(e).Elem().FieldByName("Entity")
+// scanRow hydrates the current row into the struct pointed to by e
+func scanRow(rows *sql.Rows, plan *rowsPlan, e any) error {
+	v := reflect.ValueOf(e).Elem()
+	mapper, hasMapper := plan.meta.mapper.(entityMapper)
 
-	if entityField.IsValid() {
-		if entityField.Type() != reflect.TypeOf(&Entity{}) {
-			return *e, errors.New("Invalid embedded Entity type in " + reflect.ValueOf(e).Elem().Type().String() + ": must be an embedded *Entity")
+	if plan.meta.EntityIndex != nil {
+		if plan.meta.EntityErr != nil {
+			return plan.meta.EntityErr
 		}
 
-		ref, err := entityFromRows(rows)
+		ref, err := entityFromColumns(rows, plan.columnTypes)
 
 		if err != nil {
-			return *e, err
+			return err
 		}
 
-		entityField.Set(reflect.ValueOf(ref))
-	}
-
-	columnTypes, err := rows.ColumnTypes()
-	var scan = make([]any, 0)
-
-	if err != nil {
-		return *e, err
+		if entity, ok := mapperEntity(mapper, hasMapper, e); ok {
+			*entity = ref
+		} else {
+			v.FieldByIndex(plan.meta.EntityIndex).Set(reflect.ValueOf(ref))
+		}
 	}
 
-	for _, columnType := range columnTypes {
-		fieldRef, found := getFieldRefByColumnType(e, columnType, true, true)
+	var scan = make([]any, len(plan.columnTypes))
 
-		if found {
-			scan = append(scan, fieldRef)
-		} else {
-			scan = append(scan, new(any))
+	for i, index := range plan.fields {
+		if hasMapper && !plan.prefixed[i] {
+			if f, ok := mapper.field(e, plan.columnTypes[i].Name(), true); ok {
+				scan[i] = f
+				continue
+			}
+		} else if index != nil {
+			scan[i] = v.FieldByIndex(index).Addr().Interface()
+			continue
 		}
+
+		scan[i] = new(any)
 	}
 
-	err = rows.Scan(scan...)
+	err := rows.Scan(scan...)
 
 	if err != nil {
-		return *e, fmt.Errorf("failed to execute FromRows: %w", err)
+		return fmt.Errorf("failed to execute FromRows: %w", err)
 	}
 
-	if h, ok := any(e).(Hydratable); ok {
+	if h, ok := e.(Hydratable); ok {
 		m := make(map[string]any)
-		for i, columnType := range columnTypes {
+		for i, columnType := range plan.columnTypes {
 			m[columnType.Name()] = reflect.ValueOf(scan[i]).Elem().Interface()
 		}
 		i, err := h.Hydrate(m)
+		v.Set(reflect.ValueOf(i))
 		if err != nil {
-			return i.(T), err
+			return err
 		}
-		*e = i.(T)
 	}
 
-	return *e, nil
+	bindRelations(v, plan.meta)
+
+	return nil
+}
+
+func mapperEntity(mapper entityMapper, hasMapper bool, e any) (**Entity, bool) {
+	if !hasMapper {
+		return nil, false
+	}
+
+	return mapper.entity(e)
 }
 
 func FromMap[T any, M ~map[string]any](m M) (T, error) {
 	e := new(T)
-	entityField := reflect.ValueOf(e).Elem().FieldByName("Entity")
+	err := fromMap(e, m)
+	return *e, err
+}
+
+// fromMap hydrates the struct pointed to by e, slices of maps are inflated into slice fields
+func fromMap(e any, m map[string]any) error {
+	v := reflect.ValueOf(e).Elem()
+	meta := getEntityMeta(v.Type())
 
-	if entityField.IsValid() {
-		if entityField.Type() != reflect.TypeOf(&Entity{}) {
-			return *e, errors.New("Invalid embedded Entity type in " + reflect.ValueOf(e).Elem().Type().String() + ": must be an embedded *Entity")
+	if meta.EntityIndex != nil {
+		if meta.EntityErr != nil {
+			return meta.EntityErr
 		}
 
 		ref, err := entityFromMap(m)
 
 		if err != nil {
-			return *e, err
+			return err
 		}
 
-		entityField.Set(reflect.ValueOf(ref))
+		v.FieldByIndex(meta.EntityIndex).Set(reflect.ValueOf(ref))
 	}
 
-	for k, v := range m {
-		fieldRef, found := getFieldRef(e, k, true, true)
+	mapper, hasMapper := meta.mapper.(entityMapper)
+
+	for k, val := range m {
+		var fieldRef any
+		var found bool
+
+		if hasMapper && !strings.Contains(k, columnPrefixSeparator) {
+			fieldRef, found = mapper.field(e, k, true)
+		} else if index, ok := meta.field(k, true, true); ok {
+			fieldRef, found = v.FieldByIndex(index).Addr().Interface(), true
+		}
+
+		if found && val != nil {
+			var err error
 
-		if found && v != nil {
 			if reflect.TypeOf(fieldRef).Elem().Kind() == reflect.Slice {
-				panic("Does not currently handle slices")
+				err = sliceFromMap(reflect.ValueOf(fieldRef).Elem(), val)
 			} else if reflect.TypeOf(fieldRef).Implements(typeOf[Unserializeable]()) {
-				err := convertAssign(fieldRef, v)
-				if err != nil {
-					return *e, err
-				}
-			} else if reflect.TypeOf(v).Kind() == reflect.Slice {
-				if reflect.ValueOf(v).Len() > 0 {
-					err := convertAssign(fieldRef, reflect.ValueOf(v).Index(0).Interface())
-					if err != nil {
-						return *e, err
-					}
+				err = convertAssign(fieldRef, val)
+			} else if reflect.TypeOf(val).Kind() == reflect.Slice {
+				if reflect.ValueOf(val).Len() > 0 {
+					err = convertAssign(fieldRef, reflect.ValueOf(val).Index(0).Interface())
 				}
 			} else {
-				err := convertAssign(fieldRef, v)
-				if err != nil {
-					return *e, err
-				}
+				err = convertAssign(fieldRef, val)
+			}
+
+			if err != nil {
+				return err
 			}
 		}
 	}
 
-	if h, ok := any(e).(Hydratable); ok {
+	if h, ok := e.(Hydratable); ok {
 		i, err := h.Hydrate(m)
-		if i, ok := i.(T); ok {
-			if err != nil {
-				return i, err
+		if reflect.TypeOf(i) != v.Type() {
+			return errors.New(v.Type().String() + "'s Hydrate() method must return an entity of the same type")
+		}
+		v.Set(reflect.ValueOf(i))
+		if err != nil {
+			return err
+		}
+	}
+
+	bindRelations(v, meta)
+
+	return nil
+}
+
+// sliceFromMap assigns a slice value, elements that are maps are hydrated into struct elements
+func sliceFromMap(field reflect.Value, val any) error {
+	elemType := field.Type().Elem()
+	structType := elemType
+	if structType.Kind() == reflect.Pointer {
+		structType = structType.Elem()
+	}
+
+	rv := reflect.ValueOf(val)
+	if structType.Kind() != reflect.Struct || rv.Kind() != reflect.Slice {
+		return convertAssign(field.Addr().Interface(), val)
+	}
+
+	out := reflect.MakeSlice(field.Type(), 0, rv.Len())
+
+	for i := 0; i < rv.Len(); i++ {
+		elem := rv.Index(i).Interface()
+		if m, ok := elem.(map[string]any); ok {
+			e := reflect.New(structType)
+			if err := fromMap(e.Interface(), m); err != nil {
+				return err
 			}
-			*e = i
+			if elemType.Kind() == reflect.Pointer {
+				out = reflect.Append(out, e)
+			} else {
+				out = reflect.Append(out, e.Elem())
+			}
+		} else if reflect.TypeOf(elem) == elemType {
+			out = reflect.Append(out, rv.Index(i))
 		} else {
-			return *e, errors.New(typeOf[T]().String() + "'s Hydrate() method must return an entity of the same type")
+			return fmt.Errorf("cannot assign %T to an element of %s", elem, field.Type().String())
 		}
 	}
 
-	return *e, nil
+	field.Set(out)
+	return nil
 }
 
 func ToMap[T any](entity T) map[string]any {
@@ -207,15 +290,19 @@ func (e *Entity) entityFields() map[string]field {
 }
 
 func entityFromRows(rows *sql.Rows) (*Entity, error) {
-	e := NewEntity()
-
 	columnTypes, err := rows.ColumnTypes()
-	var scan = make([]any, 0)
 
 	if err != nil {
 		return nil, err
 	}
 
+	return entityFromColumns(rows, columnTypes)
+}
+
+func entityFromColumns(rows *sql.Rows, columnTypes []*sql.ColumnType) (*Entity, error) {
+	e := NewEntity()
+	var scan = make([]any, 0, len(columnTypes))
+
 	for _, column := range columnTypes {
 		e.fields[column.Name()] = field{
 			Type:  column,
@@ -225,7 +312,7 @@ func entityFromRows(rows *sql.Rows) (*Entity, error) {
 		scan = append(scan, e.fields[column.Name()].Value)
 	}
 
-	err = rows.Scan(scan...)
+	err := rows.Scan(scan...)
 
 	if err != nil {
 		return nil, err
@@ -284,54 +371,57 @@ func entityValueToMap(v reflect.Value, onlyUpdated bool, unserialize bool, recur
 		}
 	}
 
-	for i := 0; i < v.Elem().NumField(); i++ {
-		field := v.Elem().Field(i)
-		fieldType := t.Elem().Field(i)
-		fieldName := fieldType.Tag.Get("field")
+	meta := getEntityMeta(t)
+
+	if m, ok := meta.mapper.(entityMapper); ok {
+		m.toMap(v, values, onlyUpdated, unserialize, recurse)
+		return values
+	}
+
+	for _, fm := range meta.Fields {
+		if fm.Column != "" {
+			mapFieldValue(values, fm.Column, v.Elem().Field(fm.Index), onlyUpdated, unserialize, recurse)
+		}
+	}
+
+	return values
+}
 
-		if fieldName != "" {
-			value := field.Interface()
+func mapFieldValue(values map[string]any, fieldName string, field reflect.Value, onlyUpdated bool, unserialize bool, recurse bool) {
+	value := field.Interface()
 
-			if onlyUpdated && field.IsZero() {
-				if v, has := values[fieldName]; has {
-					if v != nil && reflect.ValueOf(v).IsZero() {
-						continue
+	if onlyUpdated && field.IsZero() && !MapperKeepZero(values, fieldName) {
+		return
+	}
+
+	if field.Kind() == reflect.Struct {
+		if v, ok := value.(Unserializeable); ok && unserialize {
+			values[fieldName] = v.Unserialize()
+		} else if v, ok := value.(driver.Valuer); ok {
+			val, err := v.Value()
+			if err == nil {
+				values[fieldName] = val
+			}
+		} else if t, ok := value.(time.Time); ok {
+			values[fieldName] = t
+		} else if _, ok := value.(IEntity); ok {
+			relatedPk, hasPk := getPrimaryKeyField(value)
+			if hasPk {
+				if recurse {
+					inner := entityValueToMap(field.Addr(), onlyUpdated, unserialize, recurse)
+					for k, v := range inner {
+						values[k] = v
 					}
 				} else {
-					continue
-				}
-			}
+					pkField, has := getField(field, relatedPk.Tag.Get("field"), false, false)
 
-			if field.Kind() == reflect.Struct {
-				if v, ok := value.(Unserializeable); ok && unserialize {
-					values[fieldName] = v.Unserialize()
-				} else if v, ok := value.(driver.Valuer); ok {
-					val, err := v.Value()
-					if err == nil {
-						values[fieldName] = val
-					}
-				} else if _, ok := value.(IEntity); ok {
-					relatedPk, hasPk := getPrimaryKeyField(value)
-					if hasPk {
-						if recurse {
-							inner := entityValueToMap(field.Addr(), onlyUpdated, unserialize, recurse)
-							for k, v := range inner {
-								values[k] = v
-							}
-						} else {
-							pkField, has := getField(field, relatedPk.Tag.Get("field"), false, false)
-
-							if has && !pkField.Elem().IsZero() {
-								values[fieldName] = pkField.Elem().Interface()
-							}
-						}
+					if has && !pkField.Elem().IsZero() {
+						values[fieldName] = pkField.Elem().Interface()
 					}
 				}
-			} else {
-				values[fieldName] = value
 			}
 		}
+	} else {
+		values[fieldName] = value
 	}
-
-	return values
 }
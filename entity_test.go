@@ -100,6 +100,40 @@ func TestStructFromMap(t *testing.T) {
 	}
 }
 
+func TestStructFromMapSlice(t *testing.T) {
+	s, err := FromMap[ParentWithRelations](map[string]any{
+		"parent_id": int64(1),
+		"children": []map[string]any{
+			{"child_id": int64(1), "child_name": "Child 1"},
+			{"child_id": int64(2), "child_name": "Child 2"},
+		},
+	})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(s.Children) != 2 || s.Children[1].ID != 2 || s.Children[1].Name != "Child 2" {
+		t.Fatalf("Children were not hydrated: %+v", s.Children)
+	}
+}
+
+func TestStructFromMapPrefixed(t *testing.T) {
+	s, err := FromMap[ChildWithParents](map[string]any{
+		"child_id":            int64(1),
+		"Parent__parent_name": "First",
+		"Same__parent_name":   "Second",
+	})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if s.Parent.Name.Wrapped != "First" || s.Same.Name.Wrapped != "Second" {
+		t.Errorf("prefixed columns were not routed: %v %v", s.Parent.Name, s.Same.Name)
+	}
+}
+
 func TestToMap(t *testing.T) {
 	i := Parent{
 		ID:   int64(123),
@@ -0,0 +1,191 @@
+package db
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+var ErrInvalidEnum = errors.New("invalid enum value")
+
+// EnumType is a string or integer type that declares its allowed values, such as type status string
+type EnumType[T any] interface {
+	~string | ~int | ~int8 | ~int16 | ~int32 | ~int64 | ~uint | ~uint8 | ~uint16 | ~uint32 | ~uint64
+	EnumValues() []T
+}
+
+// Enum wraps an EnumType so that scanning or writing a value it does not declare fails with ErrInvalidEnum.
+// Enum types used directly as fields are checked by ValidateEnums, which runs before every insert and update.
+// Inserts skip zero values that are not declared, leaving them to the column default
+type Enum[T EnumType[T]] struct {
+	Wrapped T
+}
+
+func NewEnum[T EnumType[T]](v T) Enum[T] {
+	return Enum[T]{Wrapped: v}
+}
+
+func (e *Enum[T]) Scan(value any) error {
+	var v T
+	if bs, ok := value.([]byte); ok {
+		value = string(bs)
+	}
+
+	if err := convertAssign(&v, value); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidEnum, err)
+	}
+
+	if err := checkEnum(v); err != nil {
+		return err
+	}
+
+	e.Wrapped = v
+	return nil
+}
+
+func (e Enum[T]) Value() (driver.Value, error) {
+	if err := checkEnum(e.Wrapped); err != nil {
+		return nil, err
+	}
+
+	return driver.DefaultParameterConverter.ConvertValue(e.Wrapped)
+}
+
+func (e Enum[T]) Valid() bool {
+	return checkEnum(e.Wrapped) == nil
+}
+
+func (e Enum[T]) EnumValues() []T {
+	return e.Wrapped.EnumValues()
+}
+
+func checkEnum[T EnumType[T]](v T) error {
+	if !slices.Contains(v.EnumValues(), v) {
+		return fmt.Errorf("%w: %v is not one of %v", ErrInvalidEnum, v, v.EnumValues())
+	}
+	return nil
+}
+
+// EnumDDL returns the MySQL column type of a string enum, such as ENUM('active','archived')
+func EnumDDL[T interface {
+	~string
+	EnumValues() []T
+}]() string {
+	var zero T
+	values := make([]string, 0)
+
+	for _, v := range zero.EnumValues() {
+		values = append(values, "'"+strings.ReplaceAll(string(v), "'", "''")+"'")
+	}
+
+	return "ENUM(" + strings.Join(values, ",") + ")"
+}
+
+// ValidateEnums checks that the given mapped fields, or all of them when none are given, hold declared enum values.
+// Fields are enums when their type, or the type wrapped by an Enum, has an EnumValues method
+func ValidateEnums[T IEntity](entity *T, fields ...string) error {
+	return validateEnums(reflect.ValueOf(entity).Elem(), fields)
+}
+
+func validateEnums(v reflect.Value, fields []string) error {
+	meta := getEntityMeta(v.Type())
+
+	for _, f := range meta.Fields {
+		if f.Column == "" || f.Foreign != "" || (len(fields) > 0 && !slices.Contains(fields, f.Column)) {
+			continue
+		}
+
+		field, allowed, ok := enumValue(v.Field(f.Index))
+		if !ok {
+			continue
+		}
+
+		if !declaresValue(allowed, field) {
+			return fmt.Errorf("%w: %s: %v is not one of %v", ErrInvalidEnum, f.Column, field.Interface(), allowed.Interface())
+		}
+	}
+
+	return nil
+}
+
+// enumValue returns the value of an enum field, unwrapping an Enum, and its declared values. It reports false for
+// fields that are not enums
+func enumValue(field reflect.Value) (reflect.Value, reflect.Value, bool) {
+	values := field.MethodByName("EnumValues")
+	if !values.IsValid() || values.Type().NumIn() != 0 || values.Type().NumOut() != 1 {
+		return field, reflect.Value{}, false
+	}
+
+	allowed := values.Call(nil)[0]
+	if allowed.Kind() != reflect.Slice {
+		return field, reflect.Value{}, false
+	}
+
+	if field.Kind() == reflect.Struct {
+		field = field.FieldByName("Wrapped")
+	}
+
+	if !field.IsValid() || allowed.Type().Elem() != field.Type() {
+		return field, reflect.Value{}, false
+	}
+
+	return field, allowed, true
+}
+
+func declaresValue(allowed reflect.Value, v reflect.Value) bool {
+	for i := 0; i < allowed.Len(); i++ {
+		if allowed.Index(i).Interface() == v.Interface() {
+			return true
+		}
+	}
+	return false
+}
+
+// insertEnumColumns returns the columns an insert must check and the zero enum columns it must not write, so the
+// column default applies to them. Zero enums that declare their zero value are written like any other value
+func insertEnumColumns[T IEntity](entity *T) ([]string, []string) {
+	v := reflect.ValueOf(entity).Elem()
+	check := make([]string, 0)
+	omit := make([]string, 0)
+
+	for _, f := range getEntityMeta(v.Type()).Fields {
+		if f.Column == "" || f.Foreign != "" {
+			continue
+		}
+
+		field := v.Field(f.Index)
+		if !field.IsZero() {
+			check = append(check, f.Column)
+		} else if value, allowed, ok := enumValue(field); ok {
+			if declaresValue(allowed, value) {
+				check = append(check, f.Column)
+			} else {
+				omit = append(omit, f.Column)
+			}
+		}
+	}
+
+	return check, omit
+}
+
+// updateEnumColumns returns the columns an update must check: the written ones and every non-zero field, as
+// mapping drops the values an Enum refuses to write
+func updateEnumColumns[T IEntity](entity *T, written map[string]any) []string {
+	v := reflect.ValueOf(entity).Elem()
+	columns := make([]string, 0, len(written))
+
+	for k := range written {
+		columns = append(columns, k)
+	}
+
+	for _, f := range getEntityMeta(v.Type()).Fields {
+		if f.Column != "" && !v.Field(f.Index).IsZero() {
+			columns = append(columns, f.Column)
+		}
+	}
+
+	return columns
+}
@@ -0,0 +1,151 @@
+package db
+
+import (
+	"errors"
+	"testing"
+)
+
+type level int
+
+func (level) EnumValues() []level {
+	return []level{1, 2, 3}
+}
+
+type quoted string
+
+func (quoted) EnumValues() []quoted {
+	return []quoted{"on", "it's off"}
+}
+
+type leveledEntity struct {
+	*Entity
+	ID    int64        `field:"id" primary:"leveled"`
+	Level level        `field:"level"`
+	Mode  Enum[quoted] `field:"mode"`
+	Name  string       `field:"name"`
+}
+
+type priority int
+
+func (priority) EnumValues() []priority {
+	return []priority{0, 1, 2}
+}
+
+type tier string
+
+func (tier) EnumValues() []tier {
+	return []tier{"", "gold"}
+}
+
+type prioritizedEntity struct {
+	*Entity
+	ID       int64      `field:"id" primary:"prioritized"`
+	Priority priority   `field:"priority"`
+	Tier     Enum[tier] `field:"tier"`
+	Level    level      `field:"level"`
+}
+
+func TestEnum(t *testing.T) {
+	var e Enum[friendStatus]
+
+	if err := e.Scan([]byte("bad")); err != nil || e.Wrapped != "bad" {
+		t.Errorf("expected bad to be scanned, got %q and %v", e.Wrapped, err)
+	}
+
+	if err := e.Scan("ugly"); !errors.Is(err, ErrInvalidEnum) || e.Wrapped != "bad" {
+		t.Errorf("expected ugly to be rejected, got %q and %v", e.Wrapped, err)
+	}
+
+	if v, err := e.Value(); err != nil || v != "bad" {
+		t.Errorf("expected the value bad, got %v and %v", v, err)
+	}
+
+	if _, err := NewEnum[friendStatus]("").Value(); !errors.Is(err, ErrInvalidEnum) {
+		t.Errorf("expected the zero value to be rejected, got %v", err)
+	}
+
+	var l Enum[level]
+	if err := l.Scan(int64(2)); err != nil || l.Wrapped != 2 || !l.Valid() {
+		t.Errorf("expected level 2 to be scanned, got %d and %v", l.Wrapped, err)
+	}
+
+	if v, err := l.Value(); err != nil || v != int64(2) {
+		t.Errorf("expected the value 2, got %v and %v", v, err)
+	}
+
+	if err := l.Scan(int64(7)); !errors.Is(err, ErrInvalidEnum) {
+		t.Errorf("expected level 7 to be rejected, got %v", err)
+	}
+}
+
+func TestEnumDDL(t *testing.T) {
+	if ddl := EnumDDL[friendStatus](); ddl != "ENUM('good','bad')" {
+		t.Errorf("unexpected ddl %s", ddl)
+	}
+
+	if ddl := EnumDDL[quoted](); ddl != "ENUM('on','it''s off')" {
+		t.Errorf("unexpected ddl %s", ddl)
+	}
+}
+
+func TestValidateEnums(t *testing.T) {
+	e := leveledEntity{Level: 2, Mode: NewEnum[quoted]("on")}
+
+	if err := ValidateEnums(&e); err != nil {
+		t.Errorf("expected declared values to be valid, got %v", err)
+	}
+
+	e.Level = 4
+	if err := ValidateEnums(&e); !errors.Is(err, ErrInvalidEnum) {
+		t.Errorf("expected level 4 to be rejected, got %v", err)
+	}
+
+	if err := ValidateEnums(&e, "mode"); err != nil {
+		t.Errorf("only the given fields should be checked, got %v", err)
+	}
+
+	e.Mode = NewEnum[quoted]("off")
+	if err := ValidateEnums(&e, "mode"); !errors.Is(err, ErrInvalidEnum) {
+		t.Errorf("expected wrapped values to be checked, got %v", err)
+	}
+
+	if _, err := doFilterInsert(&e); !errors.Is(err, ErrInvalidEnum) {
+		t.Errorf("expected inserts to check enums, got %v", err)
+	}
+
+	if _, err := doFilterUpdate(&e); !errors.Is(err, ErrInvalidEnum) {
+		t.Errorf("expected updates to check enums that cannot be written, got %v", err)
+	}
+
+	if _, err := doFilterUpdate(&leveledEntity{ID: 1, Name: "partial"}); err != nil {
+		t.Errorf("updates should not check enums they do not write, got %v", err)
+	}
+
+	fields, err := doFilterInsert(&leveledEntity{Name: "defaulted"})
+	if err != nil {
+		t.Errorf("inserts should leave zero enums to the column default, got %v", err)
+	}
+	if _, has := fields["mode"]; has {
+		t.Errorf("a zero Enum should not be written, got %v", fields)
+	}
+	if _, has := fields["level"]; has || fields["name"] != "defaulted" {
+		t.Errorf("a zero enum type should not be written, got %v", fields)
+	}
+}
+
+func TestInsertEnums_DeclaredZero(t *testing.T) {
+	fields, err := doFilterInsert(&prioritizedEntity{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, hasPriority := fields["priority"]
+	_, hasTier := fields["tier"]
+	if !hasPriority || !hasTier {
+		t.Errorf("declared zero values should be written, got %v", fields)
+	}
+
+	if _, has := fields["level"]; has {
+		t.Errorf("undeclared zero values should be left to the column default, got %v", fields)
+	}
+}
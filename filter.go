@@ -0,0 +1,484 @@
+package db
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"reflect"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrInvalidFilter = errors.New("invalid filter")
+)
+
+type FilterOp string
+
+const (
+	FilterEq    = FilterOp("eq")
+	FilterNotEq = FilterOp("ne")
+	FilterGt    = FilterOp("gt")
+	FilterGtEq  = FilterOp("gte")
+	FilterLt    = FilterOp("lt")
+	FilterLtEq  = FilterOp("lte")
+	FilterIn    = FilterOp("in")
+	FilterNotIn = FilterOp("nin")
+	FilterLike  = FilterOp("like")
+	FilterNull  = FilterOp("null")
+)
+
+const (
+	FilterSortParam   = "sort"
+	FilterLimitParam  = "limit"
+	FilterOffsetParam = "offset"
+)
+
+// FilterRules is the allowlist of filterable columns, their operators and the sortable columns of an entity. Columns
+// of joined parents are named Path__column like their joined fields, or by their column when only one parent has it
+type FilterRules struct {
+	Fields       map[string][]FilterOp
+	Sortable     []string
+	DefaultLimit uint
+	MaxLimit     uint
+}
+
+type Filter struct {
+	Condition *ConditionSet
+	OrderBys  []Order
+	Offset    Offset
+}
+
+// Query returns a query that can be composed with GetRows, GetCount or GetChildren
+func (f *Filter) Query() *QueryBuilder {
+	q := NewQuery()
+	q.WhereCondition.Conditions = append(q.WhereCondition.Conditions, f.Condition.Conditions...)
+	q.OrderBys = append(q.OrderBys, f.OrderBys...)
+
+	if f.Offset.Limit != 0 {
+		q.Offset = f.Offset
+	}
+
+	return q
+}
+
+var filterRules = make(map[typeId]FilterRules)
+
+func DefFilterRules[T IEntity](rules FilterRules) {
+	filterRules[tId[T]()] = rules
+}
+
+// ParseFilter turns URL query parameters such as ?status=active&age[gte]=18&sort=-created&limit=20 into a Filter
+func ParseFilter[T IEntity](values url.Values) (*Filter, error) {
+	p, err := newFilterParser[T]()
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make([]string, 0)
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		vs := values[k]
+		if len(vs) == 0 {
+			continue
+		}
+
+		switch k {
+		case FilterSortParam:
+			err = p.sort(strings.Split(strings.Join(vs, ","), ","))
+		case FilterLimitParam:
+			err = p.limit(vs[len(vs)-1])
+		case FilterOffsetParam:
+			err = p.offset(vs[len(vs)-1])
+		default:
+			column, op := k, FilterEq
+			if i := strings.IndexByte(k, '['); i > 0 && strings.HasSuffix(k, "]") {
+				column, op = k[:i], FilterOp(k[i+1:len(k)-1])
+			}
+
+			for _, v := range vs {
+				var value any = v
+				if op == FilterIn || op == FilterNotIn {
+					value = strings.Split(v, ",")
+				}
+				err = p.condition(column, op, value)
+				if err != nil {
+					break
+				}
+			}
+		}
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return p.filter()
+}
+
+// ParseFilterJSON turns a JSON filter object such as {"status": "active", "age": {"gte": 18}, "sort": ["-created"]} into a Filter
+func ParseFilterJSON[T IEntity](data []byte) (*Filter, error) {
+	p, err := newFilterParser[T]()
+	if err != nil {
+		return nil, err
+	}
+
+	var m map[string]any
+	d := json.NewDecoder(bytes.NewReader(data))
+	d.UseNumber()
+	err = d.Decode(&m)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidFilter, err)
+	}
+
+	keys := make([]string, 0)
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		v := m[k]
+
+		switch k {
+		case FilterSortParam:
+			switch s := v.(type) {
+			case string:
+				err = p.sort(strings.Split(s, ","))
+			case []any:
+				fields := make([]string, 0)
+				for _, f := range s {
+					fs, ok := f.(string)
+					if !ok {
+						return nil, fmt.Errorf("%w: sort fields must be strings", ErrInvalidFilter)
+					}
+					fields = append(fields, fs)
+				}
+				err = p.sort(fields)
+			default:
+				err = fmt.Errorf("%w: sort must be a string or an array", ErrInvalidFilter)
+			}
+		case FilterLimitParam:
+			err = p.limit(asString(v))
+		case FilterOffsetParam:
+			err = p.offset(asString(v))
+		default:
+			if ops, ok := v.(map[string]any); ok {
+				opKeys := make([]string, 0)
+				for op := range ops {
+					opKeys = append(opKeys, op)
+				}
+				sort.Strings(opKeys)
+
+				for _, op := range opKeys {
+					err = p.condition(k, FilterOp(op), ops[op])
+					if err != nil {
+						break
+					}
+				}
+			} else {
+				err = p.condition(k, FilterEq, v)
+			}
+		}
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return p.filter()
+}
+
+type filterParser struct {
+	rules   FilterRules
+	columns map[string]Ident
+	f       *Filter
+}
+
+func newFilterParser[T IEntity]() (*filterParser, error) {
+	rules, ok := filterRules[tId[T]()]
+	if !ok {
+		return nil, fmt.Errorf("%w: no filter rules defined for %s", ErrInvalidFilter, typeOf[T]().String())
+	}
+
+	columns := make(map[string]Ident)
+	entityFilterColumns(typeOf[T](), columns)
+
+	return &filterParser{
+		rules:   rules,
+		columns: columns,
+		f: &Filter{
+			Condition: Condition(),
+			OrderBys:  make([]Order, 0),
+		},
+	}, nil
+}
+
+// entityFilterColumns maps the columns of an entity to its table and the columns of the parents joined by its
+// foreign fields to their join aliases, under their Path__column names like the joined fields. Parent columns are also
+// mapped under their own names unless the entity has them, names of more than one parent are left empty as they are
+// ambiguous
+func entityFilterColumns(t reflect.Type, columns map[string]Ident) {
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	table, _ := getTable(reflect.New(t).Interface())
+	own := make(map[string]bool)
+
+	for _, f := range getEntityMeta(t).Fields {
+		if f.Column == "" || f.Foreign != "" {
+			continue
+		}
+
+		own[f.Column] = true
+		if table != "" {
+			columns[f.Column] = TableField(table, f.Column)
+		} else {
+			columns[f.Column] = Ident(f.Column)
+		}
+	}
+
+	parents := make(map[string]Ident)
+	ambiguous := make(map[string]bool)
+
+	parentFilterColumns(t, table, "", map[string]bool{table: true}, func(path string, name string, column Ident) {
+		columns[path+name] = column
+
+		if _, has := parents[name]; has {
+			ambiguous[name] = true
+		}
+		parents[name] = column
+	})
+
+	for name, column := range parents {
+		if own[name] {
+			continue
+		}
+
+		if ambiguous[name] {
+			column = ""
+		}
+		columns[name] = column
+	}
+}
+
+// parentFilterColumns walks the foreign fields like joinForeignFields, so the columns get the aliases of the joins
+func parentFilterColumns(t reflect.Type, alias string, path string, used map[string]bool, add func(string, string, Ident)) {
+	for _, f := range getEntityMeta(t).Fields {
+		if f.Foreign == "" || f.Field.Type.Kind() != reflect.Struct {
+			continue
+		}
+
+		if _, has := getPrimaryKeyField(reflect.New(f.Field.Type).Interface()); !has {
+			continue
+		}
+
+		fieldPath := path + f.Field.Name
+		parentAlias := f.Foreign
+		if used[f.Foreign] {
+			parentAlias = f.Foreign + "_" + fieldPath
+		}
+		used[parentAlias] = true
+
+		for _, pf := range getEntityMeta(f.Field.Type).Fields {
+			if pf.Column != "" && pf.Foreign == "" {
+				add(fieldPath+columnPrefixSeparator, pf.Column, TableField(parentAlias, pf.Column))
+			}
+		}
+
+		parentFilterColumns(f.Field.Type, parentAlias, fieldPath+columnPrefixSeparator, used, add)
+	}
+}
+
+func (p *filterParser) column(name string) (Ident, error) {
+	column, ok := p.columns[name]
+	if !ok {
+		return "", fmt.Errorf("%w: unknown field %s", ErrInvalidFilter, name)
+	}
+	if column == "" {
+		return "", fmt.Errorf("%w: ambiguous field %s, use its Path__column name", ErrInvalidFilter, name)
+	}
+	return column, nil
+}
+
+func (p *filterParser) condition(name string, op FilterOp, value any) error {
+	column, err := p.column(name)
+	if err != nil {
+		return err
+	}
+
+	if !slices.Contains(p.rules.Fields[name], op) {
+		return fmt.Errorf("%w: operator %s is not allowed on %s", ErrInvalidFilter, op, name)
+	}
+
+	value = filterValue(value)
+	c := p.f.Condition
+
+	switch op {
+	case FilterIn, FilterNotIn:
+		list, ok := value.([]any)
+		if !ok {
+			list = []any{value}
+		}
+		for _, v := range list {
+			if err := checkFilterScalar(name, v); err != nil {
+				return err
+			}
+		}
+		if op == FilterIn {
+			c.In(column, list)
+		} else {
+			c.NotIn(column, list)
+		}
+		return nil
+	case FilterNull:
+		b, err := strconv.ParseBool(asString(value))
+		if err != nil {
+			return fmt.Errorf("%w: %s[null] must be true or false", ErrInvalidFilter, name)
+		}
+		if b {
+			c.IsNull(column)
+		} else {
+			c.IsNotNull(column)
+		}
+		return nil
+	}
+
+	if err := checkFilterScalar(name, value); err != nil {
+		return err
+	}
+
+	switch op {
+	case FilterEq:
+		c.Eq(column, value)
+	case FilterNotEq:
+		c.NotEq(column, value)
+	case FilterGt:
+		c.Gt(column, value)
+	case FilterGtEq:
+		c.GtEq(column, value)
+	case FilterLt:
+		c.Lt(column, value)
+	case FilterLtEq:
+		c.LtEq(column, value)
+	case FilterLike:
+		c.Like(column, asString(value))
+	default:
+		return fmt.Errorf("%w: unknown operator %s", ErrInvalidFilter, op)
+	}
+
+	return nil
+}
+
+// filterValue converts decoded JSON numbers to ints or floats
+func filterValue(value any) any {
+	switch v := value.(type) {
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return i
+		}
+		if f, err := v.Float64(); err == nil {
+			return f
+		}
+		return v.String()
+	case []any:
+		out := make([]any, 0, len(v))
+		for _, e := range v {
+			out = append(out, filterValue(e))
+		}
+		return out
+	case []string:
+		out := make([]any, 0, len(v))
+		for _, e := range v {
+			out = append(out, e)
+		}
+		return out
+	}
+	return value
+}
+
+func checkFilterScalar(name string, value any) error {
+	switch value.(type) {
+	case string, int64, float64, bool:
+		return nil
+	}
+	return fmt.Errorf("%w: invalid value for %s", ErrInvalidFilter, name)
+}
+
+func (p *filterParser) sort(fields []string) error {
+	for _, f := range fields {
+		f = strings.TrimSpace(f)
+		if f == "" {
+			continue
+		}
+
+		ord := Asc
+		if strings.HasPrefix(f, "-") {
+			ord = Desc
+			f = f[1:]
+		} else {
+			f = strings.TrimPrefix(f, "+")
+		}
+
+		if !slices.Contains(p.rules.Sortable, f) {
+			return fmt.Errorf("%w: %s is not sortable", ErrInvalidFilter, f)
+		}
+
+		column, err := p.column(f)
+		if err != nil {
+			return err
+		}
+
+		p.f.OrderBys = append(p.f.OrderBys, Order{Field: column, Ord: ord})
+	}
+
+	return nil
+}
+
+func (p *filterParser) limit(value string) error {
+	l, err := strconv.ParseUint(value, 10, 32)
+	if err != nil {
+		return fmt.Errorf("%w: invalid limit %q", ErrInvalidFilter, value)
+	}
+
+	if p.rules.MaxLimit != 0 && uint(l) > p.rules.MaxLimit {
+		return fmt.Errorf("%w: limit cannot exceed %d", ErrInvalidFilter, p.rules.MaxLimit)
+	}
+
+	p.f.Offset.Limit = uint(l)
+	return nil
+}
+
+func (p *filterParser) offset(value string) error {
+	o, err := strconv.ParseUint(value, 10, 32)
+	if err != nil {
+		return fmt.Errorf("%w: invalid offset %q", ErrInvalidFilter, value)
+	}
+
+	p.f.Offset.Start = uint(o)
+	return nil
+}
+
+func (p *filterParser) filter() (*Filter, error) {
+	if p.f.Offset.Limit == 0 {
+		switch {
+		case p.rules.DefaultLimit != 0:
+			p.f.Offset.Limit = p.rules.DefaultLimit
+		case p.rules.MaxLimit != 0:
+			p.f.Offset.Limit = p.rules.MaxLimit
+		case p.f.Offset.Start != 0:
+			p.f.Offset.Limit = Unlimited
+		}
+	}
+
+	return p.f, nil
+}
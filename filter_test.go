@@ -0,0 +1,149 @@
+package db
+
+import (
+	"errors"
+	"net/url"
+	"reflect"
+	"testing"
+)
+
+type parentLink struct {
+	*Entity
+	ID   int64  `field:"link_id" primary:"links"`
+	Name string `field:"parent_name"`
+	From Parent `field:"from_id" foreign:"parents"`
+	Into Parent `field:"into_id" foreign:"parents"`
+}
+
+func init() {
+	DefFilterRules[parentLink](FilterRules{
+		Fields: map[string][]FilterOp{
+			"From__parent_status": {FilterEq},
+			"Into__parent_status": {FilterEq},
+			"parent_status":       {FilterEq},
+			"parent_name":         {FilterEq},
+		},
+	})
+
+	DefFilterRules[Child](FilterRules{
+		Fields: map[string][]FilterOp{
+			"child_name":    {FilterEq, FilterLike, FilterIn},
+			"child_id":      {FilterGt, FilterGtEq, FilterLt, FilterLtEq, FilterNotIn},
+			"parent_status": {FilterEq, FilterNull},
+		},
+		Sortable:     []string{"child_name", "child_id"},
+		DefaultLimit: 20,
+		MaxLimit:     100,
+	})
+}
+
+func TestParseFilter(t *testing.T) {
+	values, _ := url.ParseQuery("parent_status=active&child_id[gte]=18&child_name[in]=a,b&sort=-child_id,child_name&limit=10&offset=30")
+
+	f, err := ParseFilter[Child](values)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sql, args, err := MySQLTranscriber{UsePlaceholders: true}.Transcribe(NewQuery().Select("*").From("children").ComposeWith(f.Query()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expectedSql := `SELECT * FROM children
+		WHERE children.child_id >= ? AND children.child_name IN(?, ?) AND parents.parent_status = ?
+		ORDER BY children.child_id DESC, children.child_name ASC
+		LIMIT 30, 10`
+
+	if normalizeSql(sql) != normalizeSql(expectedSql) {
+		t.Errorf("Failed asserting queries are the same \n%s (actual) VS:\n%s", normalizeSql(sql), normalizeSql(expectedSql))
+	}
+
+	if !reflect.DeepEqual(args, []any{"18", "a", "b", "active"}) {
+		t.Errorf("Failed asserting argument sets are the same %v", args)
+	}
+}
+
+func TestParseFilterJSON(t *testing.T) {
+	f, err := ParseFilterJSON[Child]([]byte(`{
+		"child_id": {"gt": 1, "lt": 10.5},
+		"parent_status": {"null": false},
+		"sort": ["child_name"]
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := Condition().
+		Gt(TableField("children", "child_id"), int64(1)).
+		Lt(TableField("children", "child_id"), 10.5).
+		IsNotNull(TableField("parents", "parent_status"))
+
+	if !reflect.DeepEqual(f.Condition, expected) {
+		t.Errorf("invalid condition %#v", f.Condition)
+	}
+
+	if !reflect.DeepEqual(f.OrderBys, []Order{{TableField("children", "child_name"), Asc}}) {
+		t.Errorf("invalid order %#v", f.OrderBys)
+	}
+
+	if f.Offset != (Offset{0, 20}) {
+		t.Errorf("default limit was not applied %+v", f.Offset)
+	}
+}
+
+func TestParseFilterErrors(t *testing.T) {
+	invalid := []string{
+		"unknown=1",
+		"child_name[gt]=a",
+		"child_id=1",
+		"sort=parent_status",
+		"limit=1000",
+		"limit=abc",
+		"parent_status[null]=maybe",
+	}
+
+	for _, s := range invalid {
+		values, _ := url.ParseQuery(s)
+		_, err := ParseFilter[Child](values)
+		if !errors.Is(err, ErrInvalidFilter) {
+			t.Errorf("%q should be an invalid filter, got %v", s, err)
+		}
+	}
+
+	_, err := ParseFilterJSON[Child]([]byte(`{"child_name": {"eq": {"nested": true}}}`))
+	if !errors.Is(err, ErrInvalidFilter) {
+		t.Errorf("nested values should be rejected, got %v", err)
+	}
+
+	_, err = ParseFilter[Friend](url.Values{})
+	if !errors.Is(err, ErrInvalidFilter) {
+		t.Errorf("entities without rules should be rejected, got %v", err)
+	}
+}
+
+func TestParseFilter_RepeatedParents(t *testing.T) {
+	values, _ := url.ParseQuery("From__parent_status=active&Into__parent_status=inactive&parent_name=x")
+
+	f, err := ParseFilter[parentLink](values)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sql, _, err := MySQLTranscriber{UsePlaceholders: true}.Transcribe(NewQuery().Select("*").From("links").ComposeWith(f.Query()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expectedSql := `SELECT * FROM links
+		WHERE parents.parent_status = ? AND parents_Into.parent_status = ? AND links.parent_name = ?`
+
+	if normalizeSql(sql) != normalizeSql(expectedSql) {
+		t.Errorf("Failed asserting queries are the same \n%s (actual) VS:\n%s", normalizeSql(sql), normalizeSql(expectedSql))
+	}
+
+	values, _ = url.ParseQuery("parent_status=active")
+	if _, err := ParseFilter[parentLink](values); !errors.Is(err, ErrInvalidFilter) {
+		t.Errorf("columns of both parents should be ambiguous, got %v", err)
+	}
+}
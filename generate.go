@@ -0,0 +1,152 @@
+package db
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+	"time"
+)
+
+// Generator returns a new value for the zero fields tagged generate:"<name>" when their entity is inserted
+type Generator func() any
+
+var generators = map[string]Generator{
+	"uuidv4":    func() any { return NewUUIDv4() },
+	"uuidv7":    func() any { return NewUUIDv7() },
+	"ulid":      func() any { return NewULID() },
+	"snowflake": func() any { return NextSnowflake() },
+}
+
+// RegisterGenerator should only be called from init functions, it may replace a built-in generator
+func RegisterGenerator(name string, g Generator) {
+	generators[name] = g
+}
+
+// generateKeys sets the zero fields of an entity that have a generator, before it is inserted
+func generateKeys[T IEntity](entity *T) {
+	meta := getEntityMeta(typeOf[T]())
+	v := reflect.ValueOf(entity).Elem()
+
+	for _, f := range meta.Generated {
+		field := v.Field(f.Index)
+		if !field.IsZero() {
+			continue
+		}
+
+		g, ok := generators[f.Generate]
+		if !ok {
+			panic("Unknown generator " + f.Generate + " for " + meta.Type.String() + "." + f.Field.Name)
+		}
+
+		setGenerated(field, g())
+	}
+}
+
+func setGenerated(field reflect.Value, value any) {
+	v := reflect.ValueOf(value)
+
+	switch {
+	case v.Type().AssignableTo(field.Type()):
+		field.Set(v)
+	case field.Kind() == reflect.String:
+		if s, ok := value.(fmt.Stringer); ok {
+			field.SetString(s.String())
+		} else {
+			field.SetString(fmt.Sprint(value))
+		}
+	case field.Type() == typeOf[[]byte]() && v.Kind() == reflect.Array:
+		b := make([]byte, v.Len())
+		reflect.Copy(reflect.ValueOf(b), v)
+		field.SetBytes(b)
+	case v.Type().ConvertibleTo(field.Type()):
+		field.Set(v.Convert(field.Type()))
+	default:
+		panic("Cannot assign generated " + v.Type().String() + " to " + field.Type().String())
+	}
+}
+
+// ULID is a 128 bit id that starts with the clock's unix milliseconds, written in Crockford's base 32
+type ULID [16]byte
+
+const crockford = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+func NewULID() ULID {
+	var u ULID
+	randomBytes(u[6:])
+
+	ms := uint64(clock().UnixMilli())
+	for i := 0; i < 6; i++ {
+		u[i] = byte(ms >> (40 - 8*i))
+	}
+
+	return u
+}
+
+func (u ULID) String() string {
+	s := make([]byte, 26)
+
+	// 128 bits are written as 26 characters of 5 bits, the first character holds the 3 leading bits
+	for i := 25; i >= 0; i-- {
+		bit := 128 - 5*(26-i)
+		var c byte
+
+		for b := 0; b < 5; b++ {
+			p := bit + b
+			if p < 0 {
+				continue
+			}
+			c = c<<1 | (u[p/8]>>(7-p%8))&1
+		}
+
+		s[i] = crockford[c]
+	}
+
+	return string(s)
+}
+
+// SnowflakeEpoch is the start of snowflake timestamps, ids keep increasing for about 69 years after it
+var SnowflakeEpoch = time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+
+var snowflakes = struct {
+	sync.Mutex
+	node     int64
+	last     int64
+	sequence int64
+}{}
+
+// SetSnowflakeNode sets the 10 bit node id of this process, processes that insert into the same table need distinct ids
+func SetSnowflakeNode(node int64) {
+	if node < 0 || node > 1023 {
+		panic(fmt.Sprintf("snowflake node must be between 0 and 1023, got %d", node))
+	}
+
+	snowflakes.Lock()
+	defer snowflakes.Unlock()
+
+	snowflakes.node = node
+}
+
+// NextSnowflake returns an int64 made of 41 bits of milliseconds since SnowflakeEpoch, the 10 bit node id and a 12 bit
+// sequence. When the sequence of a millisecond is exhausted the next millisecond is used, so the ids never repeat
+func NextSnowflake() int64 {
+	snowflakes.Lock()
+	defer snowflakes.Unlock()
+
+	now := clock().Sub(SnowflakeEpoch).Milliseconds()
+
+	if now <= snowflakes.last {
+		now = snowflakes.last
+		snowflakes.sequence++
+
+		if snowflakes.sequence > 4095 {
+			now++
+			snowflakes.sequence = 0
+		}
+	} else {
+		snowflakes.sequence = 0
+	}
+
+	snowflakes.last = now
+
+	return now<<22 | snowflakes.node<<12 | snowflakes.sequence
+}
@@ -0,0 +1,89 @@
+package db
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+type generatedEntity struct {
+	*Entity
+	ID        UUID       `field:"id" primary:"generated" generate:"uuidv7"`
+	Binary    BinaryUUID `field:"binary_id" generate:"uuidv4"`
+	Text      string     `field:"text_id" generate:"ulid"`
+	Snowflake int64      `field:"snowflake_id" generate:"snowflake"`
+	Custom    string     `field:"custom_id" generate:"custom"`
+}
+
+func TestGenerateKeys(t *testing.T) {
+	RegisterGenerator("custom", func() any { return "custom-1" })
+
+	e := generatedEntity{Text: "set"}
+	generateKeys(&e)
+
+	if e.ID == (UUID{}) || e.ID[6]>>4 != 7 {
+		t.Errorf("expected a v7 uuid, got %s", e.ID)
+	}
+	if e.Binary == (BinaryUUID{}) || e.Binary[6]>>4 != 4 {
+		t.Errorf("expected a v4 uuid, got %s", e.Binary)
+	}
+	if e.Text != "set" {
+		t.Errorf("set fields should not be generated, got %s", e.Text)
+	}
+	if e.Snowflake <= 0 {
+		t.Errorf("expected a snowflake, got %d", e.Snowflake)
+	}
+	if e.Custom != "custom-1" {
+		t.Errorf("expected the custom generator to be used, got %s", e.Custom)
+	}
+
+	e.Text = ""
+	generateKeys(&e)
+	if len(e.Text) != 26 {
+		t.Errorf("expected a ulid string, got %s", e.Text)
+	}
+}
+
+func TestULID(t *testing.T) {
+	if s := (ULID{}).String(); s != strings.Repeat("0", 26) {
+		t.Errorf("unexpected zero ulid %s", s)
+	}
+
+	var max ULID
+	for i := range max {
+		max[i] = 0xff
+	}
+	if s := max.String(); s != "7"+strings.Repeat("Z", 25) {
+		t.Errorf("unexpected max ulid %s", s)
+	}
+
+	SetClock(func() time.Time { return time.UnixMilli(1469918176385) })
+	defer SetClock(nil)
+
+	if s := NewULID().String(); !strings.HasPrefix(s, "01ARYZ6S41") {
+		t.Errorf("expected the ulid to start with its timestamp, got %s", s)
+	}
+}
+
+func TestNextSnowflake(t *testing.T) {
+	now := SnowflakeEpoch.Add(time.Hour)
+	SetClock(func() time.Time { return now })
+	defer SetClock(nil)
+
+	SetSnowflakeNode(5)
+	defer SetSnowflakeNode(0)
+
+	first := NextSnowflake()
+	if first>>22 < time.Hour.Milliseconds() || (first>>12)&1023 != 5 {
+		t.Errorf("unexpected snowflake layout %d", first)
+	}
+
+	last := first
+	for i := 0; i < 5000; i++ {
+		next := NextSnowflake()
+		if next <= last {
+			t.Fatalf("snowflakes must increase, got %d after %d", next, last)
+		}
+		last = next
+	}
+}
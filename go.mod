@@ -3,6 +3,6 @@ module github.com/squlpt-go/db
 go 1.21.3
 
 require (
-	github.com/go-sql-driver/mysql v1.7.1 // indirect
-	github.com/joho/godotenv v1.5.1 // indirect
+	github.com/go-sql-driver/mysql v1.7.1
+	github.com/joho/godotenv v1.5.1
 )
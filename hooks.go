@@ -0,0 +1,76 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+)
+
+// Executor runs statements for lifecycle hooks, inside the transaction of the operation when it has one
+type Executor interface {
+	DB() *sql.DB
+	Exec(query Transcribeable) *Result
+	Query(query Transcribeable) *sql.Rows
+}
+
+type executor struct {
+	db  *sql.DB
+	ex  execer
+	ctx context.Context
+}
+
+func newExecutor(ctx context.Context, db *sql.DB, ex execer) executor {
+	return executor{db: db, ex: ex, ctx: ctx}
+}
+
+func (e executor) DB() *sql.DB {
+	return e.db
+}
+
+func (e executor) Exec(query Transcribeable) *Result {
+	return execOn(e.ctx, e.db, e.ex, query)
+}
+
+func (e executor) Query(query Transcribeable) *sql.Rows {
+	return queryOn(e.ctx, e.db, e.ex, query)
+}
+
+// Lifecycle hooks are implemented on the entity pointer. Before hooks run after keys and timestamps are set and
+// before the entity is filtered, after hooks once the statement succeeded. An error from any hook aborts the
+// operation and is returned, batches run in a transaction are rolled back
+
+type IBeforeInsert interface {
+	BeforeInsert(ctx context.Context, ex Executor) error
+}
+
+type IAfterInsert interface {
+	AfterInsert(ctx context.Context, ex Executor) error
+}
+
+type IBeforeUpdate interface {
+	BeforeUpdate(ctx context.Context, ex Executor) error
+}
+
+type IAfterUpdate interface {
+	AfterUpdate(ctx context.Context, ex Executor) error
+}
+
+type IBeforeDelete interface {
+	BeforeDelete(ctx context.Context, ex Executor) error
+}
+
+type IAfterDelete interface {
+	AfterDelete(ctx context.Context, ex Executor) error
+}
+
+// IAfterLoad runs when an entity is read by Query, GetRows and the functions built on them, or eager loaded.
+// Reading panics with its error, like any other error while reading rows
+type IAfterLoad interface {
+	AfterLoad(ctx context.Context, ex Executor) error
+}
+
+func callHook[H any](ex executor, entity any, hook func(H, context.Context, Executor) error) error {
+	if h, ok := entity.(H); ok {
+		return hook(h, ex.ctx, ex)
+	}
+	return nil
+}
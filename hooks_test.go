@@ -0,0 +1,67 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+)
+
+type hookedEntity struct {
+	*Entity
+	ID    int64 `field:"id" primary:"hooked"`
+	calls int
+	err   error
+	ctx   context.Context
+}
+
+func (h *hookedEntity) BeforeUpdate(ctx context.Context, ex Executor) error {
+	h.calls++
+	h.ctx = ctx
+	return h.err
+}
+
+type hookKey struct{}
+
+func TestCallHook(t *testing.T) {
+	ctx := context.WithValue(context.Background(), hookKey{}, "request")
+	ex := newExecutor(ctx, nil, nil)
+	e := &hookedEntity{}
+
+	if err := callHook(ex, e, IBeforeUpdate.BeforeUpdate); err != nil || e.calls != 1 {
+		t.Errorf("expected the hook to be called once, got %d calls and %v", e.calls, err)
+	}
+
+	if e.ctx != ctx {
+		t.Error("expected the hook to receive the context of the operation")
+	}
+
+	if err := callHook(ex, e, IAfterUpdate.AfterUpdate); err != nil || e.calls != 1 {
+		t.Errorf("hooks that are not implemented should be skipped, got %d calls and %v", e.calls, err)
+	}
+
+	e.err = errors.New("aborted")
+	if err := callHook(ex, e, IBeforeUpdate.BeforeUpdate); err != e.err {
+		t.Errorf("expected the hook error, got %v", err)
+	}
+
+	if err := callHook(ex, *e, IBeforeUpdate.BeforeUpdate); err != nil || e.calls != 2 {
+		t.Errorf("hooks are implemented on the entity pointer, got %d calls and %v", e.calls, err)
+	}
+}
+
+func TestInTx_WithTx(t *testing.T) {
+	tx := &sql.Tx{}
+	ctx := WithTx(context.Background(), tx)
+
+	err := inTx(ctx, nil, func(ex executor) error {
+		if ex.ex != tx || ex.ctx != ctx {
+			t.Error("expected the operation to run on the transaction of the context")
+		}
+		return errors.New("done")
+	})
+
+	if err == nil || err.Error() != "done" {
+		t.Errorf("expected the error of the operation, got %v", err)
+	}
+}
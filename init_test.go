@@ -1,11 +1,14 @@
 package db
 
 import (
+	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"github.com/go-sql-driver/mysql"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
 
 	_ "github.com/go-sql-driver/mysql"
@@ -55,12 +58,162 @@ type Child struct {
 	Name   string `field:"child_name"`
 }
 
+type ParentWithRelations struct {
+	*Entity
+	ID       int64   `field:"parent_id" primary:"parents"`
+	Name     string  `field:"parent_name"`
+	Children []Child `field:"children"`
+	Friends  []*Friend
+}
+
+// VersionKeyedDocument relates to the children of the parent whose ID is its version, not to its own primary key
+type VersionKeyedDocument struct {
+	*Entity
+	ID       int64  `field:"document_id" primary:"documents"`
+	Title    string `field:"document_title"`
+	Version  uint   `field:"document_version"`
+	Children []Child
+}
+
+type LazyParent struct {
+	*Entity
+	ID       int64 `field:"parent_id" primary:"parents"`
+	Children HasMany[Child]
+	Friends  HasMany[Friend]
+}
+
+type LazyChild struct {
+	*Entity
+	ID     int64             `field:"child_id" primary:"children"`
+	Parent BelongsTo[Parent] `field:"parent_id"`
+}
+
+type ChildWithParents struct {
+	*Entity
+	ID     int64  `field:"child_id" primary:"children"`
+	Parent Parent `field:"parent_id" foreign:"parents"`
+	Same   Parent `field:"parent_id" foreign:"parents"`
+}
+
+type ParentFriend struct {
+	*Entity
+	ParentID int64  `field:"parent_id" primary:"parent_friends"`
+	FriendID int64  `field:"friend_id" primary:"parent_friends"`
+	Status   string `field:"parent_friend_status"`
+}
+
+type Token struct {
+	*Entity
+	ID   BinaryUUID `field:"token_id" primary:"tokens" generate:"uuidv7"`
+	Ref  UUID       `field:"token_ref" generate:"uuidv4"`
+	Name string     `field:"token_name"`
+}
+
+type friendStatus string
+
+func (friendStatus) EnumValues() []friendStatus {
+	return []friendStatus{"good", "bad"}
+}
+
+type RatedParentFriend struct {
+	*Entity
+	ParentID int64              `field:"parent_id" primary:"parent_friends"`
+	FriendID int64              `field:"friend_id" primary:"parent_friends"`
+	Status   Enum[friendStatus] `field:"parent_friend_status"`
+}
+
+type SecretToken struct {
+	*Entity
+	ID     BinaryUUID            `field:"token_id" primary:"tokens" generate:"uuidv7"`
+	Ref    UUID                  `field:"token_ref" generate:"uuidv4"`
+	Name   string                `field:"token_name"`
+	Secret Deterministic[string] `field:"token_secret"`
+}
+
+type LedgerEntry struct {
+	*Entity
+	ID     int64   `field:"ledger_id" primary:"ledger"`
+	Amount Decimal `field:"ledger_amount"`
+}
+
+type HookedToken struct {
+	*Entity
+	ID     BinaryUUID `field:"token_id" primary:"tokens" generate:"uuidv7"`
+	Ref    UUID       `field:"token_ref" generate:"uuidv4"`
+	Name   string     `field:"token_name"`
+	Events []string
+}
+
+var errHookRejected = errors.New("rejected by hook")
+
+func (h *HookedToken) BeforeInsert(ctx context.Context, ex Executor) error {
+	h.Events = append(h.Events, "BeforeInsert")
+	if h.Name == "rejected" {
+		return errHookRejected
+	}
+	return nil
+}
+
+func (h *HookedToken) AfterInsert(ctx context.Context, ex Executor) error {
+	h.Events = append(h.Events, "AfterInsert")
+	ex.Exec(NewQuery().Update("tokens").Set(map[string]any{"token_name": h.Name + " audited"}).WhereEq("token_id", h.ID))
+	return nil
+}
+
+func (h *HookedToken) BeforeDelete(ctx context.Context, ex Executor) error {
+	h.Events = append(h.Events, "BeforeDelete")
+	if h.Name == "kept audited" {
+		return errHookRejected
+	}
+	return nil
+}
+
+func (h *HookedToken) AfterLoad(ctx context.Context, ex Executor) error {
+	h.Events = append(h.Events, "AfterLoad")
+	return nil
+}
+
+type Document struct {
+	*Entity
+	ID      int64               `field:"document_id" primary:"documents" softdelete:"document_deleted_at"`
+	Title   string              `field:"document_title"`
+	Version uint                `field:"document_version" version:"true"`
+	Created Nullable[time.Time] `field:"document_created" autoCreate:"true"`
+	Updated Nullable[time.Time] `field:"document_updated" autoUpdate:"true"`
+}
+
 type Friend struct {
 	*Entity
 	ID   int64  `field:"friend_id" primary:"friends"`
 	Name string `field:"friend_name"`
 }
 
+type LabeledFriend struct {
+	*Entity
+	ID    int64  `field:"friend_id" primary:"friends"`
+	Name  string `field:"friend_name"`
+	Label string `computed:"friend_label" expr:"CONCAT('#', friends.friend_id, ' ', friends.friend_name)"`
+}
+
+type NamedFriend struct {
+	*Entity
+	ID      int64
+	Name    string
+	Visits  int `field:"-"`
+	Friends []Friend
+}
+
+func init() {
+	RegisterNaming[NamedFriend](Naming{
+		Column: func(field string) string {
+			return "friend_" + SnakeCase(field)
+		},
+		Table: func(typeName string) string {
+			return SnakeCase(strings.TrimPrefix(typeName, "Named")) + "s"
+		},
+	})
+}
+
 func MustGetEnv(key string) string {
 	v, ok := os.LookupEnv(key)
 
@@ -0,0 +1,115 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"reflect"
+	"time"
+)
+
+// GetRowByKey gets a row by its primary key, which is composite when several fields are tagged primary:"<table>".
+// The key is a single value, a slice of values in the order of the primary key fields, a map of columns, or a
+// struct whose fields match the primary key fields by field tag or by name
+func GetRowByKey[T IEntity](db *sql.DB, key any, qs ...*QueryBuilder) (T, bool) {
+	meta := getEntityMeta(typeOf[T]())
+	if !meta.HasPrimaryKey {
+		panic("No primary key defined in " + meta.Type.String())
+	}
+
+	r := As[T](getTableRowsByKey(newExecutor(context.Background(), db, db), meta.Type, meta.Table, keyColumns(meta, key), qs...))
+	r.db = db
+
+	return r.Row()
+}
+
+func keyColumns(meta *entityMeta, key any) map[string]any {
+	columns := make(map[string]any)
+	v := reflect.ValueOf(key)
+
+	_, isValuer := key.(driver.Valuer)
+	isScalar := isValuer || v.Type() == typeOf[[]byte]() || v.Type() == typeOf[time.Time]()
+
+	switch {
+	case !isScalar && v.Kind() == reflect.Map:
+		for _, f := range meta.PrimaryKeys {
+			kv := v.MapIndex(reflect.ValueOf(f.Column))
+			if !kv.IsValid() {
+				panic("No primary key value " + f.Column + " provided")
+			}
+			columns[f.Column] = kv.Interface()
+		}
+	case !isScalar && (v.Kind() == reflect.Slice || v.Kind() == reflect.Array):
+		if v.Len() != len(meta.PrimaryKeys) {
+			panic(fmt.Sprintf("%s has %d primary key fields, got %d values", meta.Type, len(meta.PrimaryKeys), v.Len()))
+		}
+		for i, f := range meta.PrimaryKeys {
+			columns[f.Column] = v.Index(i).Interface()
+		}
+	case !isScalar && v.Kind() == reflect.Struct:
+		for _, f := range meta.PrimaryKeys {
+			kv, ok := structKeyField(v, f)
+			if !ok {
+				panic("No primary key value " + f.Column + " provided")
+			}
+			columns[f.Column] = kv
+		}
+	default:
+		if len(meta.PrimaryKeys) != 1 {
+			panic(meta.Type.String() + " has a composite primary key, the key must hold all of its fields")
+		}
+		columns[meta.PrimaryKeys[0].Column] = key
+	}
+
+	return columns
+}
+
+func structKeyField(v reflect.Value, pk fieldMeta) (any, bool) {
+	for i := 0; i < v.NumField(); i++ {
+		f := v.Type().Field(i)
+		column := f.Tag.Get("field")
+
+		if column == pk.Column || (column == "" && f.Name == pk.Field.Name) {
+			return v.Field(i).Interface(), true
+		}
+	}
+
+	return nil, false
+}
+
+// entityKey returns the primary key columns of an entity from its mapped fields
+func entityKey(meta *entityMeta, fields map[string]any) map[string]any {
+	key := make(map[string]any)
+
+	for _, f := range meta.PrimaryKeys {
+		v, has := fields[f.Column]
+		if !has {
+			panic("No primary key value " + f.Column + " provided")
+		}
+		key[f.Column] = v
+	}
+
+	return key
+}
+
+// whereKey adds a condition on each primary key column, qualified with the table unless it is empty
+func whereKey(q *QueryBuilder, meta *entityMeta, key map[string]any, table string) *QueryBuilder {
+	for _, f := range meta.PrimaryKeys {
+		if table == "" {
+			q.WhereEq(f.Column, key[f.Column])
+		} else {
+			q.WhereEq(TableField(table, f.Column), key[f.Column])
+		}
+	}
+
+	return q
+}
+
+// keyValue returns the value of a single column key, or the columns of a composite key, for messages
+func keyValue(meta *entityMeta, key map[string]any) any {
+	if len(meta.PrimaryKeys) == 1 {
+		return key[meta.PrimaryKeys[0].Column]
+	}
+	return key
+}
@@ -0,0 +1,70 @@
+package db
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestKeyColumns(t *testing.T) {
+	meta := getEntityMeta(typeOf[ParentFriend]())
+
+	if len(meta.PrimaryKeys) != 2 || meta.Table != "parent_friends" {
+		t.Fatalf("expected a composite key of parent_friends, got %v", meta.PrimaryKeys)
+	}
+
+	expected := map[string]any{"parent_id": int64(1), "friend_id": int64(2)}
+
+	keys := []any{
+		[]any{int64(1), int64(2)},
+		[2]int64{1, 2},
+		map[string]any{"parent_id": int64(1), "friend_id": int64(2), "ignored": 3},
+		struct {
+			ParentID int64
+			Friend   int64 `field:"friend_id"`
+		}{1, 2},
+		ParentFriend{ParentID: 1, FriendID: 2, Status: "good"},
+	}
+
+	for _, key := range keys {
+		if columns := keyColumns(meta, key); !reflect.DeepEqual(columns, expected) {
+			t.Errorf("%T: expected %v, got %v", key, expected, columns)
+		}
+	}
+
+	single := keyColumns(getEntityMeta(typeOf[Child]()), 3)
+	if !reflect.DeepEqual(single, map[string]any{"child_id": 3}) {
+		t.Errorf("expected a single column key, got %v", single)
+	}
+}
+
+func TestKeyColumnsIncomplete(t *testing.T) {
+	meta := getEntityMeta(typeOf[ParentFriend]())
+
+	for _, key := range []any{1, []any{1}, map[string]any{"parent_id": 1}} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("%T: expected an incomplete key to panic", key)
+				}
+			}()
+			keyColumns(meta, key)
+		}()
+	}
+}
+
+func TestWhereKey(t *testing.T) {
+	meta := getEntityMeta(typeOf[ParentFriend]())
+	key := map[string]any{"parent_id": 1, "friend_id": 2}
+
+	q := whereKey(NewQuery().Select("*").From("parent_friends"), meta, key, "parent_friends")
+
+	s, _, err := (&MySQLTranscriber{}).Transcribe(q)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "SELECT * FROM parent_friends WHERE parent_friends.parent_id = 1 AND parent_friends.friend_id = 2"
+	if s != expected {
+		t.Errorf("expected %q, got %q", expected, s)
+	}
+}
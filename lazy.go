@@ -0,0 +1,197 @@
+package db
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"reflect"
+	"sync"
+)
+
+type lazy[T any] struct {
+	mu     sync.Mutex
+	loaded bool
+	value  T
+}
+
+// get loads the value on first access, concurrent callers wait for the first load
+func (l *lazy[T]) get(load func() T) T {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if !l.loaded {
+		l.value = load()
+		l.loaded = true
+	}
+
+	return l.value
+}
+
+func (l *lazy[T]) set(value T) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.value = value
+	l.loaded = true
+}
+
+func (l *lazy[T]) isLoaded() bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.loaded
+}
+
+type relationBinder interface {
+	bind(table string, keys map[string]any)
+}
+
+type relationPreloader interface {
+	preload(value reflect.Value)
+	relatedType() reflect.Type
+}
+
+// HasMany lazily loads the children of its entity through the OneToMany or ManyToMany relation to T's table.
+// It is bound to its entity when the entity is hydrated, copies of the entity share the loaded children. The
+// children are looked up by the column of the entity the relation names as its key
+type HasMany[T IEntity] struct {
+	table string
+	keys  map[string]any
+	state *lazy[[]T]
+}
+
+func (h *HasMany[T]) bind(table string, keys map[string]any) {
+	h.table = table
+	h.keys = keys
+	h.state = &lazy[[]T]{}
+}
+
+func (h *HasMany[T]) preload(value reflect.Value) {
+	if h.state == nil {
+		h.state = &lazy[[]T]{}
+	}
+	h.state.set(value.Interface().([]T))
+}
+
+func (h *HasMany[T]) relatedType() reflect.Type {
+	return typeOf[T]()
+}
+
+func (h HasMany[T]) Get(db *sql.DB) []T {
+	if h.state == nil {
+		panic("HasMany " + typeOf[T]().String() + " is not bound to a hydrated entity")
+	}
+
+	return h.state.get(func() []T {
+		return getChildren[T](db, h.table, h.key(db)).Slice()
+	})
+}
+
+// key returns the value of the column the relation to T's table joins on
+func (h HasMany[T]) key(db *sql.DB) any {
+	var c T
+	ct := mustGetTable(&c)
+
+	relation, ok := getRelation(db, h.table, ct).(interface{ parentKey() string })
+	if !ok {
+		panic("Invalid relation " + ct + " -> " + h.table)
+	}
+
+	column := unqualified(relation.parentKey())
+	id, ok := h.keys[column]
+	if !ok {
+		panic("HasMany " + typeOf[T]().String() + " is keyed by " + column + ", which is not a column of " + h.table)
+	}
+
+	return id
+}
+
+func (h HasMany[T]) Loaded() bool {
+	return h.state != nil && h.state.isLoaded()
+}
+
+// BelongsTo lazily loads the parent of its entity. It is tagged with the foreign key column, which it holds as its key
+type BelongsTo[T IEntity] struct {
+	key   any
+	state *lazy[*T]
+}
+
+func NewBelongsTo[T IEntity](key any) BelongsTo[T] {
+	return BelongsTo[T]{key: key, state: &lazy[*T]{}}
+}
+
+func (b *BelongsTo[T]) Scan(src any) error {
+	if bs, ok := src.([]byte); ok {
+		src = string(bs)
+	}
+
+	b.key = src
+	b.state = &lazy[*T]{}
+	return nil
+}
+
+func (b BelongsTo[T]) Value() (driver.Value, error) {
+	return b.key, nil
+}
+
+func (b *BelongsTo[T]) preload(value reflect.Value) {
+	if b.state == nil {
+		b.state = &lazy[*T]{}
+	}
+	b.state.set(value.Interface().(*T))
+}
+
+func (b *BelongsTo[T]) relatedType() reflect.Type {
+	return typeOf[T]()
+}
+
+func (b BelongsTo[T]) Key() any {
+	return b.key
+}
+
+func (b BelongsTo[T]) Get(db *sql.DB) (T, bool) {
+	var zero T
+
+	if b.state == nil || b.key == nil {
+		return zero, false
+	}
+
+	p := b.state.get(func() *T {
+		var e T
+		pk := mustGetPrimaryKeyField(&e)
+		table := getPrimaryKeyTable(pk)
+
+		if e, ok := GetRows[T](db, NewQuery().WhereEq(TableField(table, pk.Tag.Get("field")), b.key)).Row(); ok {
+			return &e
+		}
+		return nil
+	})
+
+	if p == nil {
+		return zero, false
+	}
+
+	return *p, true
+}
+
+func (b BelongsTo[T]) Loaded() bool {
+	return b.state != nil && b.state.isLoaded()
+}
+
+// bindRelations binds the HasMany fields of a hydrated entity to its column values, as the key of a relation is
+// not always the first primary key column
+func bindRelations(v reflect.Value, meta *entityMeta) {
+	if len(meta.Relations) == 0 || !meta.HasPrimaryKey {
+		return
+	}
+
+	keys := make(map[string]any)
+	for _, f := range meta.Fields {
+		if f.Column != "" && f.Foreign == "" {
+			keys[f.Column] = v.Field(f.Index).Interface()
+		}
+	}
+
+	for _, i := range meta.Relations {
+		v.Field(i).Addr().Interface().(relationBinder).bind(meta.Table, keys)
+	}
+}
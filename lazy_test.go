@@ -0,0 +1,112 @@
+package db
+
+import (
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestLazy_Concurrent(t *testing.T) {
+	var l lazy[int]
+	var loads int32
+	var wg sync.WaitGroup
+
+	for i := 0; i < 16; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			v := l.get(func() int {
+				atomic.AddInt32(&loads, 1)
+				return 42
+			})
+			if v != 42 {
+				t.Errorf("expected 42, got %d", v)
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	if loads != 1 {
+		t.Errorf("expected a single load, got %d", loads)
+	}
+}
+
+func TestHasMany_Bind(t *testing.T) {
+	p, err := FromMap[LazyParent](map[string]any{"parent_id": int64(3)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if p.Children.table != "parents" || p.Children.keys["parent_id"] != int64(3) {
+		t.Errorf("HasMany was not bound: %s %v", p.Children.table, p.Children.keys)
+	}
+
+	if p.Children.Loaded() {
+		t.Error("HasMany should not be loaded before access")
+	}
+
+	copied := p
+	copied.Children.preload(reflect.ValueOf([]Child{{ID: 1}}))
+
+	if !p.Children.Loaded() || len(p.Children.Get(nil)) != 1 {
+		t.Error("copies of an entity should share loaded children")
+	}
+}
+
+type compositeLazyParent struct {
+	*Entity
+	TenantID int64 `field:"tenant_id" primary:"composite_parents"`
+	ID       int64 `field:"composite_id" primary:"composite_parents"`
+	Children HasMany[Child]
+}
+
+func TestHasMany_CompositeKey(t *testing.T) {
+	DefRelation(nil, OneToManyDef{"composite_parents", "composite_parents.composite_id", "children", "children.composite_id"})
+	defer delete(schema, nil)
+
+	p, err := FromMap[compositeLazyParent](map[string]any{"tenant_id": int64(1), "composite_id": int64(7)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if key := p.Children.key(nil); key != int64(7) {
+		t.Errorf("expected the relation key composite_id to be 7, got %v", key)
+	}
+}
+
+func TestHasMany_Unbound(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("expected a panic when accessing an unbound relation")
+		}
+	}()
+
+	var h HasMany[Child]
+	h.Get(nil)
+}
+
+func TestBelongsTo(t *testing.T) {
+	c, err := FromMap[LazyChild](map[string]any{"child_id": int64(1), "parent_id": int64(5)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if c.Parent.Key() != int64(5) {
+		t.Errorf("expected key 5, got %v", c.Parent.Key())
+	}
+
+	if m := ToMap(c); m["parent_id"] != int64(5) {
+		t.Errorf("expected parent_id 5 in %v", m)
+	}
+
+	var empty BelongsTo[Parent]
+	if _, has := empty.Get(nil); has {
+		t.Error("an empty BelongsTo should not have a parent")
+	}
+
+	if m := ToMap(LazyChild{ID: 1}); m["parent_id"] != nil {
+		t.Errorf("an empty BelongsTo should not be saved: %v", m)
+	}
+}
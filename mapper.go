@@ -0,0 +1,90 @@
+package db
+
+import (
+	"reflect"
+)
+
+// Mapper maps an entity type without reflection. Mappers are emitted by cmd/dbgen and registered from the
+// generated init function, types without a mapper fall back to reflection
+type Mapper[T any] struct {
+	Table      string
+	PrimaryKey string
+	Columns    []string
+	// Entity returns the embedded *Entity of e, it is nil when the type has none
+	Entity func(e *T) **Entity
+	// Field returns a pointer to the field mapped to a column, nested structs are searched after direct fields
+	Field func(e *T, column string, readOnly bool) (any, bool)
+	// ToMap writes the tagged fields of e to values, following the same rules as the reflection based mapping
+	ToMap func(e *T, values map[string]any, onlyUpdated bool, unserialize bool, recurse bool)
+}
+
+type entityMapper interface {
+	columns() []string
+	entity(e any) (**Entity, bool)
+	field(e any, column string, readOnly bool) (any, bool)
+	toMap(v reflect.Value, values map[string]any, onlyUpdated bool, unserialize bool, recurse bool)
+}
+
+func (m *Mapper[T]) columns() []string {
+	return m.Columns
+}
+
+func (m *Mapper[T]) entity(e any) (**Entity, bool) {
+	if m.Entity == nil {
+		return nil, false
+	}
+	return m.Entity(e.(*T)), true
+}
+
+func (m *Mapper[T]) field(e any, column string, readOnly bool) (any, bool) {
+	return m.Field(e.(*T), column, readOnly)
+}
+
+func (m *Mapper[T]) toMap(v reflect.Value, values map[string]any, onlyUpdated bool, unserialize bool, recurse bool) {
+	m.ToMap(v.Interface().(*T), values, onlyUpdated, unserialize, recurse)
+}
+
+var mappers = map[reflect.Type]entityMapper{}
+
+// RegisterMapper should only be called from init functions. The mapper is used once its columns are checked
+// against the tags and naming convention of the type, a mapper generated for other columns panics
+func RegisterMapper[T any](m Mapper[T]) {
+	t := typeOf[T]()
+	mappers[t] = &m
+	entityMetas.Delete(t)
+}
+
+func getMapper[T any](meta *entityMeta) (*Mapper[T], bool) {
+	m, ok := meta.mapper.(*Mapper[T])
+	return m, ok
+}
+
+// MapperField returns a pointer to the field of e mapped to a column, using the registered mapper of T if there is one
+func MapperField[T any](e *T, column string, readOnly bool) (any, bool) {
+	meta := getEntityMeta(typeOf[T]())
+
+	if m, ok := getMapper[T](meta); ok {
+		return m.Field(e, column, readOnly)
+	}
+
+	if meta.Type.Kind() != reflect.Struct {
+		return nil, false
+	}
+
+	if index, found := meta.field(column, readOnly, true); found {
+		return reflect.ValueOf(e).Elem().FieldByIndex(index).Addr().Interface(), true
+	}
+
+	return nil, false
+}
+
+// MapperValue writes a field that generated code cannot map statically, such as field types and nested entities
+func MapperValue[T any](values map[string]any, column string, field *T, onlyUpdated bool, unserialize bool, recurse bool) {
+	mapFieldValue(values, column, reflect.ValueOf(field).Elem(), onlyUpdated, unserialize, recurse)
+}
+
+// MapperKeepZero reports whether a zero field value should still be written when only updated fields are mapped
+func MapperKeepZero(values map[string]any, column string) bool {
+	v, has := values[column]
+	return has && (v == nil || !reflect.ValueOf(v).IsZero())
+}
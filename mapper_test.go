@@ -0,0 +1,116 @@
+package db
+
+import (
+	"reflect"
+	"testing"
+)
+
+type mappedEntity struct {
+	*Entity
+	ID    int64            `field:"mapped_id" primary:"mapped"`
+	Name  Nullable[string] `field:"mapped_name"`
+	Total int              `computed:"mapped_total"`
+}
+
+var mappedFieldCalls int
+
+func init() {
+	RegisterMapper(Mapper[mappedEntity]{
+		Table:      "mapped",
+		PrimaryKey: "mapped_id",
+		Columns:    []string{"mapped_id", "mapped_name"},
+		Entity: func(e *mappedEntity) **Entity {
+			return &e.Entity
+		},
+		Field: func(e *mappedEntity, column string, readOnly bool) (any, bool) {
+			mappedFieldCalls++
+			switch column {
+			case "mapped_id":
+				return &e.ID, true
+			case "mapped_name":
+				return &e.Name, true
+			}
+			if readOnly {
+				switch column {
+				case "mapped_total":
+					return &e.Total, true
+				}
+			}
+			return nil, false
+		},
+		ToMap: func(e *mappedEntity, values map[string]any, onlyUpdated bool, unserialize bool, recurse bool) {
+			if !onlyUpdated || e.ID != 0 || MapperKeepZero(values, "mapped_id") {
+				values["mapped_id"] = e.ID
+			}
+			MapperValue(values, "mapped_name", &e.Name, onlyUpdated, unserialize, recurse)
+		},
+	})
+}
+
+func TestMapper(t *testing.T) {
+	mappedFieldCalls = 0
+
+	e, err := FromMap[mappedEntity](map[string]any{"mapped_id": 4, "mapped_name": "four", "mapped_total": 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if mappedFieldCalls == 0 {
+		t.Error("expected FromMap to use the registered mapper")
+	}
+
+	if e.ID != 4 || e.Name.Wrapped != "four" || e.Total != 2 {
+		t.Errorf("unexpected entity %+v", e)
+	}
+
+	expected := map[string]any{"mapped_id": int64(4), "mapped_name": "four", "mapped_total": 2}
+	if m := ToMap(e); !reflect.DeepEqual(m, expected) {
+		t.Errorf("expected %v, got %v", expected, m)
+	}
+}
+
+func TestMapperField(t *testing.T) {
+	c := Child{}
+
+	f, ok := MapperField(&c, "parent_name", true)
+	if !ok || f != &c.Parent.Name {
+		t.Error("expected the reflection fallback to find parent_name")
+	}
+
+	if _, ok := MapperField(&c, "unknown", true); ok {
+		t.Error("unknown should not be found")
+	}
+
+	e := mappedEntity{}
+	if _, ok := MapperField(&e, "mapped_total", false); ok {
+		t.Error("computed columns should only be found when reading")
+	}
+}
+
+func TestMapperKeepZero(t *testing.T) {
+	values := map[string]any{"a": nil, "b": 0, "c": 1}
+
+	for column, expected := range map[string]bool{"a": true, "b": false, "c": true, "d": false} {
+		if MapperKeepZero(values, column) != expected {
+			t.Errorf("%s: expected %v", column, expected)
+		}
+	}
+}
+
+type staleMappedEntity struct {
+	ID       int64 `field:"id" primary:"stale"`
+	FullName string
+}
+
+func TestMapper_NamingMismatch(t *testing.T) {
+	RegisterNaming[staleMappedEntity](Naming{Column: SnakeCase})
+	RegisterMapper(Mapper[staleMappedEntity]{Columns: []string{"id"}})
+
+	defer func() {
+		if recover() == nil {
+			t.Error("expected a mapper generated without the naming convention to panic")
+		}
+	}()
+
+	getEntityMeta(typeOf[staleMappedEntity]())
+}
@@ -0,0 +1,271 @@
+package db
+
+import (
+	"database/sql"
+	"errors"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+type fieldMeta struct {
+	Field    reflect.StructField
+	Index    int
+	Column   string
+	Primary  string
+	Foreign  string
+	Computed string
+	Expr     string
+	Generate string
+}
+
+// entityMeta is the mapping plan of a struct type, computed once and shared between goroutines
+type entityMeta struct {
+	Type          reflect.Type
+	Fields        []fieldMeta
+	PrimaryKey    reflect.StructField
+	PrimaryKeys   []fieldMeta
+	HasPrimaryKey bool
+	Table         string
+	EntityIndex   []int
+	EntityErr     error
+	Relations     []int
+	Generated     []fieldMeta
+	Expressions   []fieldMeta
+	Version       fieldMeta
+	HasVersion    bool
+	SoftDelete    string
+	AutoCreate    []fieldMeta
+	AutoUpdate    []fieldMeta
+	lookups       sync.Map
+	mapper        any
+}
+
+type fieldLookupKey struct {
+	name     string
+	readOnly bool
+	recurse  bool
+}
+
+type fieldLookup struct {
+	index []int
+	found bool
+}
+
+var entityMetas sync.Map
+
+func getEntityMeta(t reflect.Type) *entityMeta {
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	if m, ok := entityMetas.Load(t); ok {
+		return m.(*entityMeta)
+	}
+
+	m, _ := entityMetas.LoadOrStore(t, newEntityMeta(t))
+	return m.(*entityMeta)
+}
+
+func newEntityMeta(t reflect.Type) *entityMeta {
+	m := &entityMeta{
+		Type:   t,
+		Fields: make([]fieldMeta, 0),
+	}
+
+	if t.Kind() != reflect.Struct {
+		return m
+	}
+
+	naming := namingOf(t)
+	namedTable := naming.Table != nil && !hasPrimaryTag(t)
+
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		column := f.Tag.Get("field")
+		primary := f.Tag.Get("primary")
+
+		if column == "-" {
+			column = ""
+		} else if column == "" && naming.Column != nil && namedField(f) && f.Tag.Get("computed") == "" {
+			column = naming.Column(f.Name)
+			f.Tag = withTag(f.Tag, "field", column)
+		}
+
+		if namedTable && f.Name == "ID" && column != "" {
+			primary = naming.Table(t.Name())
+			f.Tag = withTag(f.Tag, "primary", primary)
+		}
+
+		fm := fieldMeta{
+			Field:    f,
+			Index:    i,
+			Column:   column,
+			Primary:  primary,
+			Foreign:  f.Tag.Get("foreign"),
+			Computed: f.Tag.Get("computed"),
+			Expr:     f.Tag.Get("expr"),
+			Generate: f.Tag.Get("generate"),
+		}
+
+		if fm.Primary != "" && !m.HasPrimaryKey {
+			m.PrimaryKey = f
+			m.HasPrimaryKey = true
+			m.Table = fm.Primary
+		}
+
+		if fm.Primary != "" && fm.Primary == m.Table {
+			m.PrimaryKeys = append(m.PrimaryKeys, fm)
+		}
+
+		if fm.Computed != "" && fm.Expr != "" {
+			m.Expressions = append(m.Expressions, fm)
+		}
+
+		if fm.Generate != "" {
+			m.Generated = append(m.Generated, fm)
+		}
+
+		if f.Tag.Get("version") == "true" && !m.HasVersion {
+			m.Version = fm
+			m.HasVersion = true
+		}
+
+		if f.Tag.Get("autoCreate") == "true" {
+			m.AutoCreate = append(m.AutoCreate, fm)
+		}
+
+		if f.Tag.Get("autoUpdate") == "true" {
+			m.AutoUpdate = append(m.AutoUpdate, fm)
+		}
+
+		if c := f.Tag.Get("softdelete"); c != "" && m.SoftDelete == "" {
+			m.SoftDelete = c
+		}
+
+		if reflect.PointerTo(f.Type).Implements(typeOf[relationBinder]()) {
+			m.Relations = append(m.Relations, i)
+		}
+
+		m.Fields = append(m.Fields, fm)
+	}
+
+	if f, ok := t.FieldByName("Entity"); ok {
+		if f.Type != reflect.TypeOf(&Entity{}) {
+			m.EntityErr = errors.New("Invalid embedded Entity type in " + t.String() + ": must be an embedded *Entity")
+		}
+		m.EntityIndex = f.Index
+	}
+
+	if mapper, ok := mappers[t]; ok {
+		if !sameColumns(mapper.columns(), m.Fields) {
+			panic("The generated mapper of " + t.String() + " does not map the columns of its fields, regenerate it with the naming convention of the type")
+		}
+		m.mapper = mapper
+	}
+
+	return m
+}
+
+func sameColumns(columns []string, fields []fieldMeta) bool {
+	mapped := make(map[string]bool)
+	for _, f := range fields {
+		if f.Column != "" {
+			mapped[f.Column] = true
+		}
+	}
+
+	for _, c := range columns {
+		if !mapped[c] {
+			return false
+		}
+		delete(mapped, c)
+	}
+
+	return len(mapped) == 0
+}
+
+// field returns the index path of the field mapped to a column name, following the same precedence as a
+// depth-first search: direct fields first, then nested structs in declaration order. When recursing, columns
+// prefixed with a field name, such as Parent__parent_name, are routed into that nested struct
+func (m *entityMeta) field(name string, readOnly bool, recurse bool) ([]int, bool) {
+	key := fieldLookupKey{name, readOnly, recurse}
+
+	if l, ok := m.lookups.Load(key); ok {
+		return l.(fieldLookup).index, l.(fieldLookup).found
+	}
+
+	index, found := m.findField(name, readOnly, recurse)
+	m.lookups.Store(key, fieldLookup{index, found})
+
+	return index, found
+}
+
+func (m *entityMeta) findField(name string, readOnly bool, recurse bool) ([]int, bool) {
+	if m.Type.Kind() != reflect.Struct {
+		panic("Can only get field of struct")
+	}
+
+	if recurse {
+		if head, rest, ok := strings.Cut(name, columnPrefixSeparator); ok {
+			for _, f := range m.Fields {
+				if f.Field.Name == head && f.Field.Type.Kind() == reflect.Struct {
+					if index, found := getEntityMeta(f.Field.Type).field(rest, readOnly, recurse); found {
+						return append([]int{f.Index}, index...), true
+					}
+				}
+			}
+		}
+	}
+
+	for _, f := range m.Fields {
+		if (f.Column == name && f.Foreign == "") || (readOnly && f.Computed == name) {
+			return []int{f.Index}, true
+		}
+	}
+
+	if !recurse {
+		return nil, false
+	}
+
+	for _, f := range m.Fields {
+		if f.Field.Type.Kind() == reflect.Struct {
+			if index, found := getEntityMeta(f.Field.Type).field(name, readOnly, recurse); found {
+				return append([]int{f.Index}, index...), true
+			}
+		}
+	}
+
+	return nil, false
+}
+
+// rowsPlan resolves the destination field of every column of a result set once, so it can be reused for every row
+type rowsPlan struct {
+	meta        *entityMeta
+	columnTypes []*sql.ColumnType
+	fields      [][]int
+	prefixed    []bool
+}
+
+func newRowsPlan(meta *entityMeta, rows *sql.Rows) (*rowsPlan, error) {
+	columnTypes, err := rows.ColumnTypes()
+	if err != nil {
+		return nil, err
+	}
+
+	p := &rowsPlan{
+		meta:        meta,
+		columnTypes: columnTypes,
+		fields:      make([][]int, len(columnTypes)),
+		prefixed:    make([]bool, len(columnTypes)),
+	}
+
+	for i, columnType := range columnTypes {
+		if index, found := meta.field(columnType.Name(), true, true); found {
+			p.fields[i] = index
+			p.prefixed[i] = strings.Contains(columnType.Name(), columnPrefixSeparator)
+		}
+	}
+
+	return p, nil
+}
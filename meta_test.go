@@ -0,0 +1,78 @@
+package db
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+)
+
+func TestEntityMeta(t *testing.T) {
+	m := getEntityMeta(reflect.TypeOf(&Child{}))
+
+	if m != getEntityMeta(typeOf[Child]()) {
+		t.Fatal("expected the metadata of a type to be cached")
+	}
+
+	if !m.HasPrimaryKey || m.PrimaryKey.Name != "ID" || m.Table != "children" {
+		t.Errorf("unexpected primary key %v in table %s", m.PrimaryKey.Name, m.Table)
+	}
+
+	if m.EntityIndex == nil || m.EntityErr != nil {
+		t.Errorf("expected a valid embedded entity, got %v", m.EntityErr)
+	}
+
+	index, found := m.field("child_name", false, true)
+	if !found || !reflect.DeepEqual(index, []int{3}) {
+		t.Errorf("child_name: expected [3], got %v", index)
+	}
+
+	index, found = m.field("parent_name", false, true)
+	if !found || !reflect.DeepEqual(index, []int{1, 2}) {
+		t.Errorf("parent_name: expected [1 2], got %v", index)
+	}
+
+	index, found = m.field("Parent__parent_name", true, true)
+	if !found || !reflect.DeepEqual(index, []int{1, 2}) {
+		t.Errorf("Parent__parent_name: expected [1 2], got %v", index)
+	}
+
+	if _, found = m.field("Other__parent_name", true, true); found {
+		t.Error("Other__parent_name should not be found")
+	}
+
+	if _, found = m.field("parent_name", false, false); found {
+		t.Error("parent_name should not be found without recursion")
+	}
+
+	if _, found = m.field("unknown", true, true); found {
+		t.Error("unknown should not be found")
+	}
+}
+
+func TestEntityMeta_Concurrent(t *testing.T) {
+	type concurrentEntity struct {
+		*Entity
+		ID   int64  `field:"id" primary:"concurrent"`
+		Name string `field:"name"`
+	}
+
+	var wg sync.WaitGroup
+	metas := make([]*entityMeta, 32)
+
+	for i := range metas {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			metas[i] = getEntityMeta(typeOf[concurrentEntity]())
+			metas[i].field("name", false, true)
+		}(i)
+	}
+
+	wg.Wait()
+
+	for _, m := range metas {
+		if m != metas[0] {
+			t.Fatal("expected every goroutine to share the same metadata")
+		}
+	}
+}
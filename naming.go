@@ -0,0 +1,148 @@
+package db
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Naming maps untagged exported fields to columns and, for types without a primary tag, names the table whose
+// primary key is the field named ID. Fields tagged field:"-" are never mapped. Types with a generated mapper
+// must be generated with the matching -naming flag of dbgen
+type Naming struct {
+	Column func(field string) string
+	Table  func(typeName string) string
+}
+
+var defaultNaming Naming
+var namings = map[reflect.Type]Naming{}
+
+// SetNaming sets the naming convention of every type without its own, it should only be called from init functions
+func SetNaming(n Naming) {
+	defaultNaming = n
+	entityMetas.Range(func(t any, _ any) bool {
+		entityMetas.Delete(t)
+		return true
+	})
+}
+
+// RegisterNaming should only be called from init functions
+func RegisterNaming[T any](n Naming) {
+	t := typeOf[T]()
+	namings[t] = n
+	entityMetas.Delete(t)
+}
+
+func namingOf(t reflect.Type) Naming {
+	if n, ok := namings[t]; ok {
+		return n
+	}
+	return defaultNaming
+}
+
+// SnakeCase names ParentID parent_id and HTTPServer http_server
+func SnakeCase(name string) string {
+	return strings.ToLower(strings.Join(nameWords(name), "_"))
+}
+
+// CamelCase names ParentID parentId and HTTPServer httpServer
+func CamelCase(name string) string {
+	words := nameWords(name)
+
+	for i, w := range words {
+		w = strings.ToLower(w)
+		if i > 0 {
+			w = strings.ToUpper(w[:1]) + w[1:]
+		}
+		words[i] = w
+	}
+
+	return strings.Join(words, "")
+}
+
+// nameWords splits a Go identifier into words, keeping initialisms such as ID and HTTP whole
+func nameWords(name string) []string {
+	runes := []rune(name)
+	words := make([]string, 0)
+	start := 0
+
+	for i := 1; i < len(runes); i++ {
+		prev, cur := runes[i-1], runes[i]
+		next := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+
+		if cur == '_' {
+			if i > start {
+				words = append(words, string(runes[start:i]))
+			}
+			start = i + 1
+			continue
+		}
+
+		if unicode.IsUpper(cur) && (unicode.IsLower(prev) || unicode.IsDigit(prev) || (unicode.IsUpper(prev) && next)) {
+			if i > start {
+				words = append(words, string(runes[start:i]))
+			}
+			start = i
+		}
+	}
+
+	if start < len(runes) {
+		words = append(words, string(runes[start:]))
+	}
+
+	return words
+}
+
+// namedField reports whether an untagged field holds a column value the naming convention can map. Nested
+// structs, relations and slices of entities are left to their own tags
+func namedField(f reflect.StructField) bool {
+	if !f.IsExported() || f.Anonymous {
+		return false
+	}
+
+	t := f.Type
+	pt := reflect.PointerTo(t)
+
+	if pt.Implements(typeOf[relationBinder]()) || pt.Implements(typeOf[relationPreloader]()) {
+		return false
+	}
+
+	if t == typeOf[time.Time]() || t.Implements(typeOf[driver.Valuer]()) || pt.Implements(typeOf[sql.Scanner]()) {
+		return true
+	}
+
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+		if t == typeOf[time.Time]() {
+			return true
+		}
+	}
+
+	switch t.Kind() {
+	case reflect.Struct, reflect.Map, reflect.Func, reflect.Chan, reflect.Interface, reflect.UnsafePointer, reflect.Array:
+		return false
+	case reflect.Slice:
+		return t.Elem().Kind() == reflect.Uint8
+	default:
+		return true
+	}
+}
+
+// hasPrimaryTag reports whether any field of t is tagged primary
+func hasPrimaryTag(t reflect.Type) bool {
+	for i := 0; i < t.NumField(); i++ {
+		if t.Field(i).Tag.Get("primary") != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// withTag appends a tag key to a struct tag, so code reading the tags of a mapped field sees the named column
+func withTag(tag reflect.StructTag, key string, value string) reflect.StructTag {
+	return reflect.StructTag(strings.TrimSpace(string(tag) + " " + key + ":" + strconv.Quote(value)))
+}
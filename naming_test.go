@@ -0,0 +1,56 @@
+package db
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestNamingStrategies(t *testing.T) {
+	tests := []struct {
+		name  string
+		snake string
+		camel string
+	}{
+		{"ID", "id", "id"},
+		{"ParentID", "parent_id", "parentId"},
+		{"HTTPServer", "http_server", "httpServer"},
+		{"UserName2FA", "user_name2_fa", "userName2Fa"},
+		{"Already_Snake", "already_snake", "alreadySnake"},
+	}
+
+	for _, test := range tests {
+		if s := SnakeCase(test.name); s != test.snake {
+			t.Errorf("SnakeCase(%s): expected %s, got %s", test.name, test.snake, s)
+		}
+		if c := CamelCase(test.name); c != test.camel {
+			t.Errorf("CamelCase(%s): expected %s, got %s", test.name, test.camel, c)
+		}
+	}
+}
+
+func TestNamingMeta(t *testing.T) {
+	m := getEntityMeta(reflect.TypeOf(NamedFriend{}))
+
+	if !m.HasPrimaryKey || m.Table != "friends" || mustGetPrimaryKeyFieldName(&NamedFriend{}) != "friend_id" {
+		t.Errorf("expected the ID field to be the primary key of friends, got %s in %s", m.PrimaryKey.Name, m.Table)
+	}
+
+	columns := make([]string, 0)
+	for _, f := range m.Fields {
+		if f.Column != "" {
+			columns = append(columns, f.Column)
+		}
+	}
+
+	if !reflect.DeepEqual(columns, []string{"friend_id", "friend_name"}) {
+		t.Errorf("expected only the value fields to be named, got %v", columns)
+	}
+
+	if fields := entityToMap(&NamedFriend{ID: 1, Name: "Friend 1", Visits: 2}, false, false, false); len(fields) != 2 {
+		t.Errorf("expected excluded fields not to be mapped, got %v", fields)
+	}
+
+	if _, found := getEntityMeta(reflect.TypeOf(Parent{})).field("timestamp", true, false); found {
+		t.Error("types without a naming convention should only map tagged fields")
+	}
+}
@@ -0,0 +1,1400 @@
+package db
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+var (
+	ErrSQLSyntax      = errors.New("sql syntax error")
+	ErrSQLUnsupported = errors.New("unsupported sql")
+)
+
+type sqlTokenType int
+
+const (
+	tokenEOF sqlTokenType = iota
+	tokenWord
+	tokenString
+	tokenNumber
+	tokenPlaceholder
+	tokenSymbol
+	tokenHint
+)
+
+type sqlToken struct {
+	Type  sqlTokenType
+	Text  string
+	Value string
+	Start int
+	End   int
+	Arg   int
+}
+
+// keywords that terminate an expression when they appear outside parentheses
+var sqlReserved = map[string]bool{
+	"AND": true, "OR": true, "XOR": true, "NOT": true, "IN": true, "IS": true, "LIKE": true, "BETWEEN": true,
+	"AS": true, "FROM": true, "WHERE": true, "GROUP": true, "HAVING": true, "ORDER": true, "BY": true,
+	"LIMIT": true, "OFFSET": true, "UNION": true, "JOIN": true, "LEFT": true, "RIGHT": true, "INNER": true,
+	"OUTER": true, "CROSS": true, "NATURAL": true, "STRAIGHT_JOIN": true, "ON": true, "USING": true,
+	"SET": true, "ASC": true, "DESC": true, "VALUES": true, "USE": true, "FORCE": true, "IGNORE": true,
+	"INTO": true, "ALL": true, "DISTINCT": true, "FOR": true, "SELECT": true, "DUPLICATE": true,
+	"WITH": true, "LOCK": true, "ESCAPE": true, "WINDOW": true,
+}
+
+// ParseQuery parses the SELECT, INSERT, UPDATE and DELETE subset of MySQL that a QueryBuilder can express.
+// Each ? placeholder consumes the next of args, those of LIMIT and OFFSET are bound as integers.
+func ParseQuery(sql string, args ...any) (*QueryBuilder, error) {
+	tokens, err := tokenizeSql(sql)
+	if err != nil {
+		return nil, err
+	}
+
+	placeholders := 0
+	for _, t := range tokens {
+		if t.Type == tokenPlaceholder {
+			placeholders++
+		}
+	}
+
+	if placeholders != len(args) {
+		return nil, fmt.Errorf("%w: %d placeholders but %d args", ErrSQLSyntax, placeholders, len(args))
+	}
+
+	p := &sqlParser{src: sql, tokens: tokens, args: args}
+
+	q, err := p.parseStatement()
+	if err != nil {
+		return nil, err
+	}
+
+	p.acceptSymbol(";")
+
+	if p.peek().Type != tokenEOF {
+		return nil, p.errorf(ErrSQLSyntax, "unexpected trailing input")
+	}
+
+	return q, nil
+}
+
+func tokenizeSql(sql string) ([]sqlToken, error) {
+	tokens := make([]sqlToken, 0)
+	runes := []rune(sql)
+	arg := 0
+
+	// offsets are tracked in bytes so they can be used to slice the source
+	offsets := make([]int, len(runes)+1)
+	o := 0
+	for i, r := range runes {
+		offsets[i] = o
+		o += len(string(r))
+	}
+	offsets[len(runes)] = o
+
+	for i := 0; i < len(runes); {
+		ch := runes[i]
+		start := i
+
+		switch {
+		case unicode.IsSpace(ch):
+			i++
+			continue
+		case ch == '/' && i+1 < len(runes) && runes[i+1] == '*':
+			end := strings.Index(string(runes[i+2:]), "*/")
+			if end < 0 {
+				return nil, fmt.Errorf("%w at position %d: unterminated comment", ErrSQLSyntax, offsets[i])
+			}
+			body := string(runes[i+2:])[:end]
+			i += 2 + len([]rune(body)) + 2
+			if strings.HasPrefix(body, "+") {
+				tokens = append(tokens, sqlToken{Type: tokenHint, Text: string(runes[start:i]), Value: strings.TrimSpace(body[1:]), Start: offsets[start], End: offsets[i]})
+			}
+			continue
+		case ch == '#' || (ch == '-' && i+1 < len(runes) && runes[i+1] == '-' && (i+2 == len(runes) || unicode.IsSpace(runes[i+2]))):
+			for i < len(runes) && runes[i] != '\n' {
+				i++
+			}
+			continue
+		case ch == '\'' || ch == '"':
+			var b strings.Builder
+			i++
+			closed := false
+			for i < len(runes) {
+				c := runes[i]
+				if c == '\\' && i+1 < len(runes) {
+					b.WriteRune(unescapeSqlRune(runes[i+1]))
+					i += 2
+					continue
+				}
+				if c == ch {
+					if i+1 < len(runes) && runes[i+1] == ch {
+						b.WriteRune(ch)
+						i += 2
+						continue
+					}
+					i++
+					closed = true
+					break
+				}
+				b.WriteRune(c)
+				i++
+			}
+			if !closed {
+				return nil, fmt.Errorf("%w at position %d: unterminated string", ErrSQLSyntax, offsets[start])
+			}
+			tokens = append(tokens, sqlToken{Type: tokenString, Text: string(runes[start:i]), Value: b.String(), Start: offsets[start], End: offsets[i]})
+			continue
+		case unicode.IsDigit(ch) || (ch == '.' && i+1 < len(runes) && unicode.IsDigit(runes[i+1])):
+			for i < len(runes) && (unicode.IsDigit(runes[i]) || runes[i] == '.') {
+				i++
+			}
+			if i < len(runes) && (runes[i] == 'e' || runes[i] == 'E') {
+				j := i + 1
+				if j < len(runes) && (runes[j] == '+' || runes[j] == '-') {
+					j++
+				}
+				if j < len(runes) && unicode.IsDigit(runes[j]) {
+					i = j
+					for i < len(runes) && unicode.IsDigit(runes[i]) {
+						i++
+					}
+				}
+			}
+			tokens = append(tokens, sqlToken{Type: tokenNumber, Text: string(runes[start:i]), Value: string(runes[start:i]), Start: offsets[start], End: offsets[i]})
+			continue
+		case isSqlWordRune(ch) || ch == '`':
+			for i < len(runes) {
+				if runes[i] == '`' {
+					end := strings.IndexRune(string(runes[i+1:]), '`')
+					if end < 0 {
+						return nil, fmt.Errorf("%w at position %d: unterminated identifier", ErrSQLSyntax, offsets[i])
+					}
+					i += 2 + len([]rune(string(runes[i+1:])[:end]))
+				} else if isSqlWordRune(runes[i]) {
+					for i < len(runes) && isSqlWordRune(runes[i]) {
+						i++
+					}
+				} else {
+					break
+				}
+
+				// qualified identifiers such as table.field, table.* or `table`.`field`
+				if i+1 < len(runes) && runes[i] == '.' && (isSqlWordRune(runes[i+1]) || runes[i+1] == '`' || runes[i+1] == '*') {
+					i++
+					if runes[i] == '*' {
+						i++
+						break
+					}
+					continue
+				}
+				break
+			}
+			text := string(runes[start:i])
+			tokens = append(tokens, sqlToken{Type: tokenWord, Text: text, Value: strings.ToUpper(text), Start: offsets[start], End: offsets[i]})
+			continue
+		case ch == '?':
+			i++
+			tokens = append(tokens, sqlToken{Type: tokenPlaceholder, Text: "?", Start: offsets[start], End: offsets[i], Arg: arg})
+			arg++
+			continue
+		}
+
+		symbol := string(ch)
+		for _, op := range []string{"<=>", "<=", ">=", "<>", "!=", "&&", "||"} {
+			if strings.HasPrefix(string(runes[i:]), op) {
+				symbol = op
+				break
+			}
+		}
+
+		if !strings.Contains("=<>!(),.*+-/%;&|", string(ch)) {
+			return nil, fmt.Errorf("%w at position %d: unexpected character %q", ErrSQLSyntax, offsets[i], ch)
+		}
+
+		i += len([]rune(symbol))
+		tokens = append(tokens, sqlToken{Type: tokenSymbol, Text: symbol, Value: symbol, Start: offsets[start], End: offsets[i]})
+	}
+
+	tokens = append(tokens, sqlToken{Type: tokenEOF, Start: len(sql), End: len(sql)})
+
+	return tokens, nil
+}
+
+func isSqlWordRune(r rune) bool {
+	return r == '_' || r == '$' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+func unescapeSqlRune(r rune) rune {
+	switch r {
+	case 'n':
+		return '\n'
+	case 't':
+		return '\t'
+	case 'r':
+		return '\r'
+	case '0':
+		return 0
+	case 'b':
+		return '\b'
+	case 'Z':
+		return 26
+	}
+	return r
+}
+
+type sqlParser struct {
+	src    string
+	tokens []sqlToken
+	pos    int
+	args   []any
+}
+
+func (p *sqlParser) peek() sqlToken {
+	return p.tokens[p.pos]
+}
+
+func (p *sqlParser) peekAt(offset int) sqlToken {
+	if p.pos+offset >= len(p.tokens) {
+		return p.tokens[len(p.tokens)-1]
+	}
+	return p.tokens[p.pos+offset]
+}
+
+func (p *sqlParser) next() sqlToken {
+	t := p.tokens[p.pos]
+	if t.Type != tokenEOF {
+		p.pos++
+	}
+	return t
+}
+
+func (p *sqlParser) isKeyword(keywords ...string) bool {
+	t := p.peek()
+	if t.Type != tokenWord {
+		return false
+	}
+	for _, k := range keywords {
+		if t.Value == k {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *sqlParser) acceptKeyword(keywords ...string) bool {
+	if p.isKeyword(keywords...) {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *sqlParser) expectKeyword(keyword string) error {
+	if !p.acceptKeyword(keyword) {
+		return p.errorf(ErrSQLSyntax, "expected "+keyword)
+	}
+	return nil
+}
+
+func (p *sqlParser) isSymbol(symbols ...string) bool {
+	t := p.peek()
+	if t.Type != tokenSymbol {
+		return false
+	}
+	for _, s := range symbols {
+		if t.Value == s {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *sqlParser) acceptSymbol(symbol string) bool {
+	if p.isSymbol(symbol) {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *sqlParser) expectSymbol(symbol string) error {
+	if !p.acceptSymbol(symbol) {
+		return p.errorf(ErrSQLSyntax, "expected '"+symbol+"'")
+	}
+	return nil
+}
+
+func (p *sqlParser) errorf(kind error, message string) error {
+	t := p.peek()
+	near := t.Text
+	if t.Type == tokenEOF {
+		near = "end of input"
+	}
+	return fmt.Errorf("%w at position %d near %q: %s", kind, t.Start, near, message)
+}
+
+func (p *sqlParser) parseStatement() (*QueryBuilder, error) {
+	switch {
+	case p.isKeyword("SELECT"):
+		return p.parseSelect()
+	case p.isKeyword("INSERT"):
+		return p.parseInsert()
+	case p.isKeyword("UPDATE"):
+		return p.parseUpdate()
+	case p.isKeyword("DELETE"):
+		return p.parseDelete()
+	case p.isKeyword("REPLACE", "WITH", "CREATE", "ALTER", "DROP", "TRUNCATE"):
+		return nil, p.errorf(ErrSQLUnsupported, "only SELECT, INSERT, UPDATE and DELETE statements are supported")
+	}
+
+	return nil, p.errorf(ErrSQLSyntax, "expected a statement")
+}
+
+func (p *sqlParser) parseOptimizerHints(q *QueryBuilder) {
+	for p.peek().Type == tokenHint {
+		q.OptimizerHint(p.next().Value)
+	}
+}
+
+func (p *sqlParser) parseSelect() (*QueryBuilder, error) {
+	q := NewQuery()
+	q.Type = Select
+	p.next()
+	p.parseOptimizerHints(q)
+
+	if p.isKeyword("DISTINCT", "DISTINCTROW", "HIGH_PRIORITY", "SQL_CALC_FOUND_ROWS") {
+		return nil, p.errorf(ErrSQLUnsupported, "select modifiers are not supported")
+	}
+	p.acceptKeyword("ALL")
+
+	for {
+		f, err := p.parseSelectField()
+		if err != nil {
+			return nil, err
+		}
+		q.AddField(f)
+
+		if !p.acceptSymbol(",") {
+			break
+		}
+	}
+
+	if p.acceptKeyword("FROM") {
+		err := p.parseTableReference(q)
+		if err != nil {
+			return nil, err
+		}
+
+		err = p.parseJoins(q)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err := p.parseWhere(q)
+	if err != nil {
+		return nil, err
+	}
+
+	if p.acceptKeyword("GROUP") {
+		if err := p.expectKeyword("BY"); err != nil {
+			return nil, err
+		}
+		for {
+			v, err := p.parseExpr()
+			if err != nil {
+				return nil, err
+			}
+			q.GroupBy(v)
+			if !p.acceptSymbol(",") {
+				break
+			}
+		}
+	}
+
+	if p.acceptKeyword("HAVING") {
+		c, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		addConditions(q.HavingCondition, c)
+	}
+
+	err = p.parseOrderLimit(q)
+	if err != nil {
+		return nil, err
+	}
+
+	for p.acceptKeyword("UNION") {
+		unionType := UnionDefault
+		if p.acceptKeyword("ALL") {
+			unionType = UnionAll
+		} else {
+			p.acceptKeyword("DISTINCT")
+		}
+
+		if !p.isKeyword("SELECT") {
+			return nil, p.errorf(ErrSQLSyntax, "expected SELECT after UNION")
+		}
+
+		u, err := p.parseSelect()
+		if err != nil {
+			return nil, err
+		}
+
+		// nested unions are flattened onto the first query
+		unions := u.Unions
+		u.Unions = make([]Union, 0)
+		q.Unions = append(q.Unions, Union{Query: u, UnionType: unionType})
+		q.Unions = append(q.Unions, unions...)
+	}
+
+	if p.isKeyword("FOR", "LOCK", "INTO") {
+		return nil, p.errorf(ErrSQLUnsupported, "locking and INTO clauses are not supported")
+	}
+
+	return q, nil
+}
+
+func (p *sqlParser) parseInsert() (*QueryBuilder, error) {
+	q := NewQuery()
+	q.Type = Insert
+	p.next()
+	p.parseOptimizerHints(q)
+
+	if p.isKeyword("LOW_PRIORITY", "DELAYED", "HIGH_PRIORITY") {
+		return nil, p.errorf(ErrSQLUnsupported, "insert modifiers are not supported")
+	}
+
+	if p.acceptKeyword("IGNORE") {
+		q.Type = InsertIgnore
+	}
+
+	if err := p.expectKeyword("INTO"); err != nil {
+		return nil, err
+	}
+
+	table, err := p.parseIdent()
+	if err != nil {
+		return nil, err
+	}
+	q.PrimaryTable = table
+
+	switch {
+	case p.acceptKeyword("SET"):
+		values, err := p.parseAssignments()
+		if err != nil {
+			return nil, err
+		}
+		q.Set(values)
+	case p.isSymbol("("):
+		p.next()
+		columns := make([]string, 0)
+		for {
+			c, err := p.parseIdent()
+			if err != nil {
+				return nil, err
+			}
+			columns = append(columns, string(c))
+			if !p.acceptSymbol(",") {
+				break
+			}
+		}
+		if err := p.expectSymbol(")"); err != nil {
+			return nil, err
+		}
+
+		if p.isKeyword("SELECT") {
+			return nil, p.errorf(ErrSQLUnsupported, "INSERT ... SELECT is not supported")
+		}
+		if !p.acceptKeyword("VALUES", "VALUE") {
+			return nil, p.errorf(ErrSQLSyntax, "expected VALUES")
+		}
+		if err := p.expectSymbol("("); err != nil {
+			return nil, err
+		}
+
+		values := make(map[string]any)
+		for i := 0; ; i++ {
+			v, err := p.parseExpr()
+			if err != nil {
+				return nil, err
+			}
+			if i >= len(columns) {
+				return nil, p.errorf(ErrSQLSyntax, "more values than columns")
+			}
+			values[columns[i]] = v
+			if !p.acceptSymbol(",") {
+				if i != len(columns)-1 {
+					return nil, p.errorf(ErrSQLSyntax, "fewer values than columns")
+				}
+				break
+			}
+		}
+		if err := p.expectSymbol(")"); err != nil {
+			return nil, err
+		}
+		if p.isSymbol(",") {
+			return nil, p.errorf(ErrSQLUnsupported, "multi-row inserts are not supported")
+		}
+		q.Set(values)
+	default:
+		return nil, p.errorf(ErrSQLSyntax, "expected SET or a column list")
+	}
+
+	if p.acceptKeyword("ON") {
+		for _, k := range []string{"DUPLICATE", "KEY", "UPDATE"} {
+			if err := p.expectKeyword(k); err != nil {
+				return nil, err
+			}
+		}
+
+		if q.Type == InsertIgnore {
+			return nil, p.errorf(ErrSQLUnsupported, "INSERT IGNORE cannot be combined with ON DUPLICATE KEY UPDATE")
+		}
+
+		updates, err := p.parseAssignments()
+		if err != nil {
+			return nil, err
+		}
+
+		// the QueryBuilder reuses the inserted values for the update clause
+		if !reflect.DeepEqual(updates, q.Values) {
+			return nil, p.errorf(ErrSQLUnsupported, "ON DUPLICATE KEY UPDATE must repeat the inserted values")
+		}
+		q.Type = InsertUpdate
+	}
+
+	return q, nil
+}
+
+func (p *sqlParser) parseUpdate() (*QueryBuilder, error) {
+	q := NewQuery()
+	q.Type = Update
+	p.next()
+	p.parseOptimizerHints(q)
+
+	if p.isKeyword("LOW_PRIORITY", "IGNORE") {
+		return nil, p.errorf(ErrSQLUnsupported, "update modifiers are not supported")
+	}
+
+	err := p.parseTableReference(q)
+	if err != nil {
+		return nil, err
+	}
+
+	err = p.parseJoins(q)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := p.expectKeyword("SET"); err != nil {
+		return nil, err
+	}
+
+	values, err := p.parseAssignments()
+	if err != nil {
+		return nil, err
+	}
+	q.Set(values)
+
+	err = p.parseWhere(q)
+	if err != nil {
+		return nil, err
+	}
+
+	err = p.parseOrderLimit(q)
+	if err != nil {
+		return nil, err
+	}
+
+	return q, nil
+}
+
+func (p *sqlParser) parseDelete() (*QueryBuilder, error) {
+	q := NewQuery()
+	q.Type = Delete
+	p.next()
+	p.parseOptimizerHints(q)
+
+	if p.isKeyword("LOW_PRIORITY", "QUICK", "IGNORE") {
+		return nil, p.errorf(ErrSQLUnsupported, "delete modifiers are not supported")
+	}
+
+	if !p.isKeyword("FROM") {
+		for {
+			f, err := p.parseIdent()
+			if err != nil {
+				return nil, err
+			}
+			q.AddField(f)
+			if !p.acceptSymbol(",") {
+				break
+			}
+		}
+	}
+
+	if err := p.expectKeyword("FROM"); err != nil {
+		return nil, err
+	}
+
+	err := p.parseTableReference(q)
+	if err != nil {
+		return nil, err
+	}
+
+	err = p.parseJoins(q)
+	if err != nil {
+		return nil, err
+	}
+
+	err = p.parseWhere(q)
+	if err != nil {
+		return nil, err
+	}
+
+	err = p.parseOrderLimit(q)
+	if err != nil {
+		return nil, err
+	}
+
+	return q, nil
+}
+
+func (p *sqlParser) parseWhere(q *QueryBuilder) error {
+	if p.acceptKeyword("WHERE") {
+		c, err := p.parseOr()
+		if err != nil {
+			return err
+		}
+		addConditions(q.WhereCondition, c)
+	}
+	return nil
+}
+
+func (p *sqlParser) parseOrderLimit(q *QueryBuilder) error {
+	if p.acceptKeyword("ORDER") {
+		if err := p.expectKeyword("BY"); err != nil {
+			return err
+		}
+		for {
+			v, err := p.parseExpr()
+			if err != nil {
+				return err
+			}
+			ord := Asc
+			if p.acceptKeyword("DESC") {
+				ord = Desc
+			} else {
+				p.acceptKeyword("ASC")
+			}
+			q.OrderBy(v, ord)
+			if !p.acceptSymbol(",") {
+				break
+			}
+		}
+	}
+
+	if p.acceptKeyword("LIMIT") {
+		first, err := p.parseUint()
+		if err != nil {
+			return err
+		}
+
+		if p.acceptSymbol(",") {
+			limit, err := p.parseUint()
+			if err != nil {
+				return err
+			}
+			q.Limit(first, limit)
+		} else if p.acceptKeyword("OFFSET") {
+			start, err := p.parseUint()
+			if err != nil {
+				return err
+			}
+			q.Limit(start, first)
+		} else {
+			q.Limit(OffsetStart, first)
+		}
+	}
+
+	return nil
+}
+
+// parseUint parses an integer or a placeholder, whose arg is bound as the integer
+func (p *sqlParser) parseUint() (uint, error) {
+	t := p.peek()
+	if t.Type == tokenPlaceholder {
+		var n uint32
+		if err := convertAssign(&n, p.args[t.Arg]); err != nil {
+			return 0, p.errorf(ErrSQLSyntax, "expected an integer argument")
+		}
+		p.next()
+		return uint(n), nil
+	}
+
+	if t.Type != tokenNumber {
+		return 0, p.errorf(ErrSQLSyntax, "expected an integer")
+	}
+	n, err := strconv.ParseUint(t.Value, 10, 32)
+	if err != nil {
+		return 0, p.errorf(ErrSQLSyntax, "expected an integer")
+	}
+	p.next()
+	return uint(n), nil
+}
+
+func (p *sqlParser) parseIdent() (Ident, error) {
+	t := p.peek()
+	if t.Type != tokenWord || sqlReserved[t.Value] {
+		return "", p.errorf(ErrSQLSyntax, "expected an identifier")
+	}
+	p.next()
+	return Ident(t.Text), nil
+}
+
+func (p *sqlParser) parseAlias() (string, bool, error) {
+	if p.acceptKeyword("AS") {
+		t := p.peek()
+		if t.Type != tokenWord && t.Type != tokenString {
+			return "", false, p.errorf(ErrSQLSyntax, "expected an alias")
+		}
+		p.next()
+		return t.Text, true, nil
+	}
+
+	t := p.peek()
+	if t.Type == tokenWord && !sqlReserved[t.Value] {
+		p.next()
+		return t.Text, true, nil
+	}
+
+	return "", false, nil
+}
+
+func (p *sqlParser) parseSelectField() (Value, error) {
+	if p.acceptSymbol("*") {
+		return Ident("*"), nil
+	}
+
+	start := p.pos
+	v, err := p.parseExpr()
+	if err != nil {
+		return nil, err
+	}
+	end := p.pos
+
+	alias, hasAlias, err := p.parseAlias()
+	if err != nil {
+		return nil, err
+	}
+
+	if !hasAlias {
+		return v, nil
+	}
+
+	switch val := v.(type) {
+	case *QueryBuilder:
+		val.Alias = Ident(alias)
+		return val, nil
+	case Ident:
+		return Ident(string(val) + " AS " + alias), nil
+	}
+
+	r := p.raw(start, end)
+	r.Query += " AS " + alias
+	return r, nil
+}
+
+func (p *sqlParser) parseTableReference(q *QueryBuilder) error {
+	t, err := p.parseTable()
+	if err != nil {
+		return err
+	}
+	q.PrimaryTable = t
+
+	hints, err := p.parseIndexHints()
+	if err != nil {
+		return err
+	}
+	q.PrimaryHints = append(q.PrimaryHints, hints...)
+
+	if p.isSymbol(",") {
+		return p.errorf(ErrSQLUnsupported, "comma joins are not supported, use JOIN ... ON")
+	}
+
+	return nil
+}
+
+func (p *sqlParser) parseTable() (Value, error) {
+	if p.isSymbol("(") {
+		if !(p.peekAt(1).Type == tokenWord && p.peekAt(1).Value == "SELECT") {
+			return nil, p.errorf(ErrSQLUnsupported, "parenthesised table references are not supported")
+		}
+		p.next()
+		sub, err := p.parseSelect()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expectSymbol(")"); err != nil {
+			return nil, err
+		}
+		alias, hasAlias, err := p.parseAlias()
+		if err != nil {
+			return nil, err
+		}
+		if !hasAlias {
+			return nil, p.errorf(ErrSQLSyntax, "derived tables must have an alias")
+		}
+		sub.Alias = Ident(alias)
+		return sub, nil
+	}
+
+	table, err := p.parseIdent()
+	if err != nil {
+		return nil, err
+	}
+
+	alias, hasAlias, err := p.parseAlias()
+	if err != nil {
+		return nil, err
+	}
+
+	if hasAlias {
+		return Ident(string(table) + " AS " + alias), nil
+	}
+
+	return table, nil
+}
+
+func (p *sqlParser) parseIndexHints() ([]IndexHint, error) {
+	hints := make([]IndexHint, 0)
+
+	for p.isKeyword("USE", "FORCE", "IGNORE") {
+		var hintType IndexHintType
+		switch p.next().Value {
+		case "USE":
+			hintType = UseIndex
+		case "FORCE":
+			hintType = ForceIndex
+		case "IGNORE":
+			hintType = IgnoreIndex
+		}
+
+		if !p.acceptKeyword("INDEX", "KEY") {
+			return nil, p.errorf(ErrSQLSyntax, "expected INDEX")
+		}
+
+		if p.isKeyword("FOR") {
+			return nil, p.errorf(ErrSQLUnsupported, "index hints with a FOR clause are not supported")
+		}
+
+		if err := p.expectSymbol("("); err != nil {
+			return nil, err
+		}
+
+		indexes := make([]string, 0)
+		for !p.isSymbol(")") {
+			t := p.next()
+			if t.Type != tokenWord {
+				return nil, p.errorf(ErrSQLSyntax, "expected an index name")
+			}
+			indexes = append(indexes, t.Text)
+			if !p.acceptSymbol(",") {
+				break
+			}
+		}
+
+		if err := p.expectSymbol(")"); err != nil {
+			return nil, err
+		}
+
+		hints = append(hints, IndexHint{hintType, indexes})
+	}
+
+	return hints, nil
+}
+
+func (p *sqlParser) parseJoins(q *QueryBuilder) error {
+	for {
+		var joinType JoinType
+
+		switch {
+		case p.acceptKeyword("LEFT"):
+			p.acceptKeyword("OUTER")
+			joinType = LeftJoin
+		case p.acceptKeyword("RIGHT"):
+			p.acceptKeyword("OUTER")
+			joinType = RightJoin
+		case p.acceptKeyword("INNER"):
+			joinType = InnerJoin
+		case p.isKeyword("JOIN"):
+			joinType = InnerJoin
+		case p.isKeyword("CROSS", "NATURAL", "STRAIGHT_JOIN", "FULL"):
+			return p.errorf(ErrSQLUnsupported, "only LEFT, RIGHT and INNER joins are supported")
+		default:
+			return nil
+		}
+
+		if err := p.expectKeyword("JOIN"); err != nil {
+			return err
+		}
+
+		table, err := p.parseTable()
+		if err != nil {
+			return err
+		}
+
+		hints, err := p.parseIndexHints()
+		if err != nil {
+			return err
+		}
+
+		if p.isKeyword("USING") {
+			return p.errorf(ErrSQLUnsupported, "JOIN ... USING is not supported, use JOIN ... ON")
+		}
+
+		if err := p.expectKeyword("ON"); err != nil {
+			return err
+		}
+
+		c, err := p.parseOr()
+		if err != nil {
+			return err
+		}
+
+		join := Join{JoinType: joinType, Table: table, Condition: Condition()}
+		addConditions(join.Condition, c)
+		if len(hints) > 0 {
+			join.Hints = hints
+		}
+		q.Joins = append(q.Joins, join)
+	}
+}
+
+func (p *sqlParser) parseAssignments() (map[string]any, error) {
+	values := make(map[string]any)
+
+	for {
+		column, err := p.parseIdent()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expectSymbol("="); err != nil {
+			return nil, err
+		}
+		v, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		values[string(column)] = v
+
+		if !p.acceptSymbol(",") {
+			break
+		}
+	}
+
+	return values, nil
+}
+
+// addConditions merges a parsed condition tree into an existing AND set
+func addConditions(target *ConditionSet, c *ConditionSet) {
+	if c.Conj == ConjAnd && !bool(c.Not) {
+		target.Conditions = append(target.Conditions, c.Conditions...)
+	} else {
+		target.Condition(c)
+	}
+}
+
+func (p *sqlParser) parseOr() (*ConditionSet, error) {
+	sets := make([]*ConditionSet, 0)
+
+	for {
+		c, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		sets = append(sets, c)
+
+		if !p.acceptKeyword("OR") && !p.acceptSymbol("||") {
+			break
+		}
+	}
+
+	if len(sets) == 1 {
+		return sets[0], nil
+	}
+
+	or := Or()
+	for _, s := range sets {
+		if len(s.Conditions) == 1 && !bool(s.Not) {
+			or.Conditions = append(or.Conditions, s.Conditions[0])
+		} else {
+			or.Condition(s)
+		}
+	}
+
+	return or, nil
+}
+
+func (p *sqlParser) parseAnd() (*ConditionSet, error) {
+	and := Condition()
+
+	for {
+		if p.isKeyword("XOR") {
+			return nil, p.errorf(ErrSQLUnsupported, "XOR is not supported")
+		}
+
+		c, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+
+		// a parenthesised AND group is flattened into its parent
+		if s, ok := c.(*ConditionSet); ok && s.Conj == ConjAnd && !bool(s.Not) {
+			and.Conditions = append(and.Conditions, s.Conditions...)
+		} else {
+			and.Conditions = append(and.Conditions, c)
+		}
+
+		if p.isKeyword("XOR") {
+			return nil, p.errorf(ErrSQLUnsupported, "XOR is not supported")
+		}
+
+		if !p.acceptKeyword("AND") && !p.acceptSymbol("&&") {
+			break
+		}
+	}
+
+	return and, nil
+}
+
+func (p *sqlParser) parseNot() (any, error) {
+	if p.acceptKeyword("NOT") || p.acceptSymbol("!") {
+		c, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return negateCondition(c), nil
+	}
+
+	if p.isSymbol("(") && !(p.peekAt(1).Type == tokenWord && p.peekAt(1).Value == "SELECT") {
+		// try a nested condition first, and fall back to an expression such as (a + b) > c
+		start := p.pos
+		p.next()
+		c, err := p.parseOr()
+		if err == nil && p.acceptSymbol(")") {
+			if len(c.Conditions) == 1 && !bool(c.Not) {
+				return c.Conditions[0], nil
+			}
+			return c, nil
+		}
+		p.pos = start
+	}
+
+	return p.parsePredicate()
+}
+
+func negateCondition(c any) any {
+	switch c := c.(type) {
+	case Eq:
+		c.Not = !c.Not
+		return c
+	case Gt:
+		c.Not = !c.Not
+		return c
+	case GtEq:
+		c.Not = !c.Not
+		return c
+	case Lt:
+		c.Not = !c.Not
+		return c
+	case LtEq:
+		c.Not = !c.Not
+		return c
+	case In:
+		c.Not = !c.Not
+		return c
+	case IsNull:
+		c.Not = !c.Not
+		return c
+	case IsTrue:
+		c.Not = !c.Not
+		return c
+	case IsFalse:
+		c.Not = !c.Not
+		return c
+	case Like:
+		c.Not = !c.Not
+		return c
+	case *ConditionSet:
+		c.Not = !c.Not
+		return c
+	}
+
+	panic("Invalid condition type " + fmt.Sprintf("%T", c))
+}
+
+func (p *sqlParser) parsePredicate() (any, error) {
+	left, err := p.parseExpr()
+	if err != nil {
+		return nil, err
+	}
+
+	if p.peek().Type == tokenSymbol {
+		op := p.peek().Value
+		switch op {
+		case "=", "!=", "<>", "<", "<=", ">", ">=":
+			p.next()
+			right, err := p.parseExpr()
+			if err != nil {
+				return nil, err
+			}
+			switch op {
+			case "=":
+				return Eq{Left: left, Right: right}, nil
+			case "!=", "<>":
+				return Eq{Left: left, Right: right, Not: true}, nil
+			case "<":
+				return Lt{Left: left, Right: right}, nil
+			case "<=":
+				return LtEq{Left: left, Right: right}, nil
+			case ">":
+				return Gt{Left: left, Right: right}, nil
+			case ">=":
+				return GtEq{Left: left, Right: right}, nil
+			}
+		case "<=>":
+			return nil, p.errorf(ErrSQLUnsupported, "the <=> operator is not supported")
+		}
+	}
+
+	if p.acceptKeyword("IS") {
+		not := Neg(p.acceptKeyword("NOT"))
+		switch {
+		case p.acceptKeyword("NULL"):
+			return IsNull{Value: left, Not: not}, nil
+		case p.acceptKeyword("TRUE"):
+			return IsTrue{Value: left, Not: not}, nil
+		case p.acceptKeyword("FALSE"):
+			return IsFalse{Value: left, Not: not}, nil
+		}
+		return nil, p.errorf(ErrSQLUnsupported, "expected NULL, TRUE or FALSE after IS")
+	}
+
+	not := Neg(p.acceptKeyword("NOT"))
+
+	switch {
+	case p.acceptKeyword("IN"):
+		if err := p.expectSymbol("("); err != nil {
+			return nil, err
+		}
+		if p.isKeyword("SELECT") {
+			sub, err := p.parseSelect()
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expectSymbol(")"); err != nil {
+				return nil, err
+			}
+			return In{Left: left, Right: sub, Not: not}, nil
+		}
+		if p.isSymbol(")") {
+			return nil, p.errorf(ErrSQLSyntax, "IN requires at least one value")
+		}
+		list := make(List, 0)
+		for !p.isSymbol(")") {
+			v, err := p.parseExpr()
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, v)
+			if !p.acceptSymbol(",") {
+				break
+			}
+		}
+		if err := p.expectSymbol(")"); err != nil {
+			return nil, err
+		}
+		return In{Left: left, Right: list, Not: not}, nil
+	case p.acceptKeyword("LIKE"):
+		right, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		if p.isKeyword("ESCAPE") {
+			return nil, p.errorf(ErrSQLUnsupported, "LIKE ... ESCAPE is not supported")
+		}
+		return Like{Left: left, Right: right, Not: not}, nil
+	case p.acceptKeyword("BETWEEN"):
+		low, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expectKeyword("AND"); err != nil {
+			return nil, err
+		}
+		high, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		c := Condition()
+		c.Conditions = append(c.Conditions, GtEq{Left: left, Right: low}, LtEq{Left: left, Right: high})
+		c.Not = not
+		return c, nil
+	}
+
+	if not {
+		return nil, p.errorf(ErrSQLSyntax, "expected IN, LIKE or BETWEEN after NOT")
+	}
+
+	return nil, p.errorf(ErrSQLUnsupported, "expected a comparison")
+}
+
+// parseExpr consumes a single operand. Identifiers, literals, placeholders and subqueries become typed values,
+// anything more complex such as function calls or arithmetic is kept verbatim as a Raw fragment.
+func (p *sqlParser) parseExpr() (Value, error) {
+	start := p.pos
+	depth := 0
+
+	for {
+		t := p.peek()
+
+		if t.Type == tokenEOF || (depth == 0 && isExprTerminator(t)) {
+			break
+		}
+
+		switch {
+		case t.Type == tokenSymbol && t.Value == "(":
+			depth++
+		case t.Type == tokenSymbol && t.Value == ")":
+			depth--
+		case t.Type == tokenWord && t.Value == "CASE":
+			if err := p.skipCase(); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		p.next()
+	}
+
+	end := p.pos
+
+	if end == start {
+		return nil, p.errorf(ErrSQLSyntax, "expected an expression")
+	}
+
+	if end-start == 1 {
+		return p.simpleValue(p.tokens[start])
+	}
+
+	first := p.tokens[start]
+	second := p.tokens[start+1]
+
+	if end-start == 2 && first.Type == tokenSymbol && first.Value == "-" && second.Type == tokenNumber {
+		v, err := p.simpleValue(second)
+		if err != nil {
+			return nil, err
+		}
+		switch v := v.(type) {
+		case Int:
+			return -v, nil
+		case Float:
+			return -v, nil
+		}
+	}
+
+	if first.Type == tokenSymbol && first.Value == "(" && second.Type == tokenWord && second.Value == "SELECT" &&
+		p.tokens[end-1].Type == tokenSymbol && p.tokens[end-1].Value == ")" {
+		p.pos = start + 1
+		sub, err := p.parseSelect()
+		if err == nil && p.pos == end-1 {
+			p.pos = end
+			return sub, nil
+		}
+		p.pos = end
+	}
+
+	return p.raw(start, end), nil
+}
+
+func isExprTerminator(t sqlToken) bool {
+	switch t.Type {
+	case tokenWord:
+		return sqlReserved[t.Value]
+	case tokenSymbol:
+		switch t.Value {
+		case ",", ")", ";", "=", "!=", "<>", "<", "<=", ">", ">=", "<=>", "&&", "||":
+			return true
+		}
+	}
+	return false
+}
+
+func (p *sqlParser) skipCase() error {
+	depth := 0
+
+	for {
+		t := p.next()
+		switch {
+		case t.Type == tokenEOF:
+			return p.errorf(ErrSQLSyntax, "unterminated CASE expression")
+		case t.Type == tokenWord && t.Value == "CASE":
+			depth++
+		case t.Type == tokenWord && t.Value == "END":
+			depth--
+			if depth == 0 {
+				return nil
+			}
+		}
+	}
+}
+
+func (p *sqlParser) simpleValue(t sqlToken) (Value, error) {
+	switch t.Type {
+	case tokenString:
+		return String(t.Value), nil
+	case tokenNumber:
+		if i, err := strconv.Atoi(t.Value); err == nil {
+			return Int(i), nil
+		}
+		f, err := strconv.ParseFloat(t.Value, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%w at position %d: invalid number %q", ErrSQLSyntax, t.Start, t.Text)
+		}
+		return Float(f), nil
+	case tokenPlaceholder:
+		return RValue(p.args[t.Arg]), nil
+	case tokenWord:
+		switch t.Value {
+		case "NULL":
+			return Null{}, nil
+		case "TRUE", "FALSE":
+			return Bool(t.Value == "TRUE"), nil
+		}
+		return Ident(t.Text), nil
+	case tokenSymbol:
+		if t.Value == "*" {
+			return Ident("*"), nil
+		}
+	}
+
+	return nil, fmt.Errorf("%w at position %d near %q: expected an expression", ErrSQLSyntax, t.Start, t.Text)
+}
+
+func (p *sqlParser) raw(start int, end int) RawQuery {
+	args := make([]any, 0)
+
+	for i := start; i < end; i++ {
+		if p.tokens[i].Type == tokenPlaceholder {
+			args = append(args, p.args[p.tokens[i].Arg])
+		}
+	}
+
+	return Raw(p.src[p.tokens[start].Start:p.tokens[end-1].End], args...)
+}
@@ -0,0 +1,201 @@
+package db
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func assertParsedSql(t *testing.T, in string, expected string, args ...any) {
+	t.Helper()
+	transcriber := MySQLTranscriber{}
+
+	q, err := ParseQuery(in, args...)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sql, _, err := transcriber.Transcribe(q)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if normalizeSql(sql) != normalizeSql(expected) {
+		t.Errorf("Failed asserting queries are the same \n%s (actual) VS:\n%s", normalizeSql(sql), normalizeSql(expected))
+	}
+}
+
+func TestParseSelect(t *testing.T) {
+	assertParsedSql(t,
+		`SELECT users.*, COUNT(*) AS count, (SELECT name FROM roles WHERE roles.role_id = users.role_id) AS role
+		FROM users FORCE INDEX (users_created)
+		LEFT JOIN profiles p ON p.user_id = users.user_id
+		WHERE users.status IN ('active', 'pending') AND (age > 17 OR age IS NULL) AND name NOT LIKE 'test%'
+		GROUP BY users.user_id
+		HAVING count >= 2
+		ORDER BY users.name DESC, age
+		LIMIT 20, 10`,
+		`SELECT users.*, COUNT(*) AS count, (SELECT name FROM roles WHERE roles.role_id = users.role_id) AS role
+		FROM users FORCE INDEX (users_created)
+		LEFT JOIN profiles AS p ON p.user_id = users.user_id
+		WHERE users.status IN('active', 'pending') AND (age > 17 OR age IS NULL) AND name NOT LIKE 'test%'
+		GROUP BY users.user_id
+		HAVING count >= 2
+		ORDER BY users.name DESC, age ASC
+		LIMIT 20, 10`)
+}
+
+func TestParseSelectStructure(t *testing.T) {
+	q, err := ParseQuery("SELECT /*+ MAX_EXECUTION_TIME(1000) */ name FROM users WHERE id = ? AND NOT (a = 1 OR b != 2) LIMIT 5 OFFSET 10", 12)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if q.Type != Select || q.PrimaryTable != Ident("users") {
+		t.Errorf("invalid query %+v", q)
+	}
+
+	if !reflect.DeepEqual(q.OptimizerHints, []string{"MAX_EXECUTION_TIME(1000)"}) {
+		t.Errorf("invalid optimizer hints %v", q.OptimizerHints)
+	}
+
+	expected := Condition()
+	expected.Conditions = []any{
+		Eq{Left: Ident("id"), Right: Int(12)},
+		&ConditionSet{
+			Not:  true,
+			Conj: ConjOr,
+			Conditions: []any{
+				Eq{Left: Ident("a"), Right: Int(1)},
+				Eq{Left: Ident("b"), Right: Int(2), Not: true},
+			},
+		},
+	}
+
+	if !reflect.DeepEqual(q.WhereCondition, expected) {
+		t.Errorf("invalid where condition %#v", q.WhereCondition)
+	}
+
+	if q.Offset != (Offset{10, 5}) {
+		t.Errorf("invalid offset %+v", q.Offset)
+	}
+}
+
+func TestParseSelectLimitPlaceholders(t *testing.T) {
+	q, err := ParseQuery("SELECT name FROM users WHERE id > ? LIMIT ? OFFSET ?", 3, 20, uint(40))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if q.Offset != (Offset{40, 20}) {
+		t.Errorf("invalid offset %+v", q.Offset)
+	}
+
+	q, err = ParseQuery("SELECT name FROM users LIMIT ?, ?", int64(10), "5")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if q.Offset != (Offset{10, 5}) {
+		t.Errorf("invalid offset %+v", q.Offset)
+	}
+
+	if _, err := ParseQuery("SELECT name FROM users LIMIT ?", -1); !errors.Is(err, ErrSQLSyntax) {
+		t.Errorf("negative limits should be a syntax error, got %v", err)
+	}
+}
+
+func TestParseSelectUnion(t *testing.T) {
+	assertParsedSql(t,
+		"SELECT a FROM t1 WHERE b BETWEEN 1 AND 5 UNION ALL SELECT a FROM t2 WHERE c IN (SELECT c FROM t3)",
+		"SELECT a FROM t1 WHERE b >= 1 AND b <= 5 UNION ALL SELECT a FROM t2 WHERE c IN(SELECT c FROM t3)")
+}
+
+func TestParseInsert(t *testing.T) {
+	assertParsedSql(t,
+		"INSERT INTO users (name, age, note) VALUES ('O\\'Brien', -4, NULL)",
+		"INSERT INTO users SET age = -4, name = 'O\\'Brien', note = NULL")
+
+	assertParsedSql(t,
+		"INSERT IGNORE INTO users SET name = ?, age = 4",
+		"INSERT IGNORE INTO users SET age = 4, name = 'x'", "x")
+
+	assertParsedSql(t,
+		"INSERT INTO users SET name = 'x' ON DUPLICATE KEY UPDATE name = 'x'",
+		"INSERT INTO users SET name = 'x' ON DUPLICATE KEY UPDATE name = 'x'")
+}
+
+func TestParseUpdate(t *testing.T) {
+	assertParsedSql(t,
+		"UPDATE users INNER JOIN profiles ON profiles.profile_id = users.profile_id SET score = score + 1, name = 'x' WHERE category IS NOT NULL ORDER BY id LIMIT 1",
+		"UPDATE users INNER JOIN profiles ON profiles.profile_id = users.profile_id SET name = 'x', score = score + 1 WHERE category IS NOT NULL ORDER BY id ASC LIMIT 1")
+}
+
+func TestParseDelete(t *testing.T) {
+	assertParsedSql(t,
+		"DELETE users.* FROM users INNER JOIN profiles ON profiles.profile_id = users.profile_id WHERE category = 5",
+		"DELETE users.* FROM users INNER JOIN profiles ON profiles.profile_id = users.profile_id WHERE category = 5")
+
+	assertParsedSql(t,
+		"DELETE FROM users WHERE created < DATE_SUB(NOW(), INTERVAL 1 DAY)",
+		"DELETE FROM users WHERE created < DATE_SUB(NOW(), INTERVAL 1 DAY)")
+}
+
+func TestParseComposeWith(t *testing.T) {
+	q, err := ParseQuery("SELECT * FROM users WHERE active = 1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	q.ComposeWith(NewQuery().WhereEq("age", 18))
+
+	sql, args, err := MySQLTranscriber{UsePlaceholders: true}.Transcribe(q)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if normalizeSql(sql) != "SELECT * FROM users WHERE active = ? AND age = ?" || !reflect.DeepEqual(args, []any{1, 18}) {
+		t.Errorf("invalid composed query %s %v", sql, args)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	unsupported := []string{
+		"SELECT DISTINCT name FROM users",
+		"SELECT * FROM users, roles",
+		"SELECT * FROM users CROSS JOIN roles",
+		"SELECT * FROM users JOIN roles USING (role_id)",
+		"INSERT INTO users (a) VALUES (1), (2)",
+		"INSERT INTO users (a) SELECT a FROM t",
+		"INSERT INTO users SET a = 1 ON DUPLICATE KEY UPDATE a = a + 1",
+		"TRUNCATE users",
+		"SELECT * FROM users WHERE a <=> b",
+		"SELECT * FROM users WHERE a = 1 XOR b = 2",
+		"SELECT * FROM users WHERE XOR b = 2",
+	}
+
+	for _, s := range unsupported {
+		_, err := ParseQuery(s)
+		if !errors.Is(err, ErrSQLUnsupported) {
+			t.Errorf("%q should be unsupported, got %v", s, err)
+		}
+	}
+
+	invalid := []string{
+		"SELECT * FROM",
+		"SELECT * FROM users WHERE",
+		"SELECT * FROM users WHERE name = 'unterminated",
+		"SELECT * FROM users LIMIT x",
+		"SELECT * FROM users WHERE id = ?",
+		"UPDATE users WHERE id = 1",
+		"SELECT * FROM users WHERE id IN ()",
+		"SELECT * FROM users WHERE id NOT IN ()",
+	}
+
+	for _, s := range invalid {
+		_, err := ParseQuery(s)
+		if !errors.Is(err, ErrSQLSyntax) {
+			t.Errorf("%q should be a syntax error, got %v", s, err)
+		}
+	}
+}
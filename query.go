@@ -2,7 +2,9 @@ package db
 
 import (
 	"database/sql"
+	"fmt"
 	"math"
+	"reflect"
 )
 
 type JoinType string
@@ -17,6 +19,20 @@ type Join struct {
 	JoinType  JoinType
 	Table     Value
 	Condition *ConditionSet
+	Hints     []IndexHint
+}
+
+type IndexHintType string
+
+const (
+	UseIndex    = IndexHintType("USE INDEX")
+	ForceIndex  = IndexHintType("FORCE INDEX")
+	IgnoreIndex = IndexHintType("IGNORE INDEX")
+)
+
+type IndexHint struct {
+	Type    IndexHintType
+	Indexes []string
 }
 
 type Ord string
@@ -62,12 +78,23 @@ type Union struct {
 	UnionType UnionType
 }
 
+type TrashedScope string
+
+const (
+	TrashedExclude = TrashedScope("")
+	TrashedWith    = TrashedScope("WITH")
+	TrashedOnly    = TrashedScope("ONLY")
+)
+
 type QueryBuilder struct {
 	Type            QueryType
 	Fields          List
 	FieldsCleared   bool
 	Values          map[string]any
+	ValueRows       []map[string]any
 	PrimaryTable    Value
+	PrimaryHints    []IndexHint
+	OptimizerHints  []string
 	Alias           Ident
 	Joins           []Join
 	WhereCondition  *ConditionSet
@@ -77,6 +104,10 @@ type QueryBuilder struct {
 	OrderBysCleared bool
 	Offset          Offset
 	Unions          []Union
+	Returnings      List
+	Trashed         TrashedScope
+	// err records a builder call that could not be applied, Transcribe returns it
+	err error
 }
 
 func NewQuery() *QueryBuilder {
@@ -89,6 +120,7 @@ func NewQuery() *QueryBuilder {
 		HavingCondition: Condition(),
 		OrderBys:        make([]Order, 0),
 		Unions:          make([]Union, 0),
+		Returnings:      make([]Value, 0),
 	}
 }
 
@@ -100,6 +132,10 @@ func (q *QueryBuilder) ComposeWith(queries ...*QueryBuilder) *QueryBuilder {
 }
 
 func (q *QueryBuilder) compose(query *QueryBuilder) {
+	if q.err == nil {
+		q.err = query.err
+	}
+
 	if query.FieldsCleared {
 		q.Fields = make([]Value, 0)
 		q.FieldsCleared = true
@@ -109,11 +145,15 @@ func (q *QueryBuilder) compose(query *QueryBuilder) {
 	for k, v := range query.Values {
 		q.Values[k] = v
 	}
+	q.ValueRows = append(q.ValueRows, query.ValueRows...)
 
 	if query.PrimaryTable != nil {
 		q.PrimaryTable = query.PrimaryTable
 	}
 
+	q.PrimaryHints = append(q.PrimaryHints, query.PrimaryHints...)
+	q.OptimizerHints = append(q.OptimizerHints, query.OptimizerHints...)
+
 	if query.Alias != "" {
 		q.Alias = query.Alias
 	}
@@ -133,6 +173,11 @@ func (q *QueryBuilder) compose(query *QueryBuilder) {
 	}
 
 	q.Unions = append(q.Unions, query.Unions...)
+	q.Returnings = append(q.Returnings, query.Returnings...)
+
+	if query.Trashed != TrashedExclude {
+		q.Trashed = query.Trashed
+	}
 }
 
 func (q *QueryBuilder) Select(fields ...any) *QueryBuilder {
@@ -206,10 +251,17 @@ func (q *QueryBuilder) ClearFields() *QueryBuilder {
 	return q
 }
 
+// Set sets the values of an insert or update. A slice of maps adds rows to a multi-row insert, columns missing from
+// a row are inserted with their default
 func (q *QueryBuilder) Set(values any) *QueryBuilder {
 	var vs map[string]any
 	var ok bool
 
+	if rows, isRows := values.([]map[string]any); isRows {
+		q.ValueRows = append(q.ValueRows, rows...)
+		return q
+	}
+
 	if vs, ok = values.(map[string]any); !ok {
 		panic("Invalid query type for Set()")
 	}
@@ -224,7 +276,7 @@ func (q *QueryBuilder) Set(values any) *QueryBuilder {
 func (q *QueryBuilder) LeftJoin(table any, condition *ConditionSet) *QueryBuilder {
 	q.Joins = append(
 		q.Joins,
-		Join{LeftJoin, LValue(table), condition},
+		Join{JoinType: LeftJoin, Table: LValue(table), Condition: condition},
 	)
 	return q
 }
@@ -244,7 +296,7 @@ func (q *QueryBuilder) LeftJoinEq(table any, left any, right any) *QueryBuilder
 func (q *QueryBuilder) InnerJoin(table any, condition *ConditionSet) *QueryBuilder {
 	q.Joins = append(
 		q.Joins,
-		Join{InnerJoin, LValue(table), condition},
+		Join{JoinType: InnerJoin, Table: LValue(table), Condition: condition},
 	)
 	return q
 }
@@ -264,7 +316,7 @@ func (q *QueryBuilder) InnerJoinEq(table any, left any, right any) *QueryBuilder
 func (q *QueryBuilder) RightJoin(table any, condition *ConditionSet) *QueryBuilder {
 	q.Joins = append(
 		q.Joins,
-		Join{RightJoin, LValue(table), condition},
+		Join{JoinType: RightJoin, Table: LValue(table), Condition: condition},
 	)
 	return q
 }
@@ -281,6 +333,45 @@ func (q *QueryBuilder) RightJoinEq(table any, left any, right any) *QueryBuilder
 	return q
 }
 
+func (q *QueryBuilder) UseIndex(indexes ...string) *QueryBuilder {
+	q.PrimaryHints = append(q.PrimaryHints, IndexHint{UseIndex, indexes})
+	return q
+}
+
+func (q *QueryBuilder) ForceIndex(indexes ...string) *QueryBuilder {
+	q.PrimaryHints = append(q.PrimaryHints, IndexHint{ForceIndex, indexes})
+	return q
+}
+
+func (q *QueryBuilder) IgnoreIndex(indexes ...string) *QueryBuilder {
+	q.PrimaryHints = append(q.PrimaryHints, IndexHint{IgnoreIndex, indexes})
+	return q
+}
+
+// JoinHint adds an index hint to the most recently added join on table
+func (q *QueryBuilder) JoinHint(table any, hintType IndexHintType, indexes ...string) *QueryBuilder {
+	t := LValue(table)
+
+	for i := len(q.Joins) - 1; i >= 0; i-- {
+		if reflect.DeepEqual(q.Joins[i].Table, t) {
+			q.Joins[i].Hints = append(q.Joins[i].Hints, IndexHint{hintType, indexes})
+			return q
+		}
+	}
+
+	if q.err == nil {
+		q.err = fmt.Errorf("no join found on %v for index hint", table)
+	}
+
+	return q
+}
+
+// OptimizerHint adds a statement-level optimizer hint, e.g. "MAX_EXECUTION_TIME(1000)"
+func (q *QueryBuilder) OptimizerHint(hint string) *QueryBuilder {
+	q.OptimizerHints = append(q.OptimizerHints, hint)
+	return q
+}
+
 func (q *QueryBuilder) Where(c *ConditionSet) *QueryBuilder {
 	q.WhereCondition.Condition(c)
 	return q
@@ -464,6 +555,26 @@ func (q *QueryBuilder) ClearOrderBys() *QueryBuilder {
 	return q
 }
 
+// Returning adds fields to the RETURNING clause of an insert, only transcribers that support it accept the clause
+func (q *QueryBuilder) Returning(fields ...any) *QueryBuilder {
+	for _, f := range fields {
+		q.Returnings = append(q.Returnings, LValue(f))
+	}
+	return q
+}
+
+// WithTrashed includes soft deleted rows in the results of GetRows, GetRowById, GetCount and GetChildren
+func (q *QueryBuilder) WithTrashed() *QueryBuilder {
+	q.Trashed = TrashedWith
+	return q
+}
+
+// OnlyTrashed restricts the results of GetRows, GetRowById, GetCount and GetChildren to soft deleted rows
+func (q *QueryBuilder) OnlyTrashed() *QueryBuilder {
+	q.Trashed = TrashedOnly
+	return q
+}
+
 func (q *QueryBuilder) Limit(start uint, limit uint) *QueryBuilder {
 	q.Offset = Offset{start, limit}
 	return q
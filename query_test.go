@@ -1068,3 +1068,55 @@ func TestQuery_UnionAll(t *testing.T) {
 		t.Error("UnionAll() did not set the correct union type")
 	}
 }
+
+func TestQuery_IndexHints(t *testing.T) {
+	q := NewQuery().Select("name").From("users").UseIndex("idx1").IgnoreIndex("idx2", "idx3")
+
+	expected := []IndexHint{
+		{UseIndex, []string{"idx1"}},
+		{IgnoreIndex, []string{"idx2", "idx3"}},
+	}
+
+	if !reflect.DeepEqual(q.PrimaryHints, expected) {
+		t.Error("Index hints were not added to the primary table")
+	}
+}
+
+func TestQuery_JoinHint(t *testing.T) {
+	q := NewQuery().
+		Select("name").
+		From("users").
+		LeftJoinEq("roles", "role_id", "id").
+		JoinHint("roles", ForceIndex, "idx1")
+
+	if !reflect.DeepEqual(q.Joins[0].Hints, []IndexHint{{ForceIndex, []string{"idx1"}}}) {
+		t.Error("JoinHint() did not add the hint to the join")
+	}
+}
+
+func TestQuery_ComposeHints(t *testing.T) {
+	q1 := NewQuery().Select("name").From("users").UseIndex("idx1")
+	q2 := NewQuery().OptimizerHint("MAX_EXECUTION_TIME(1000)").UseIndex("idx2")
+
+	q1.ComposeWith(q2)
+
+	if len(q1.PrimaryHints) != 2 || len(q1.OptimizerHints) != 1 {
+		t.Error("ComposeWith() did not merge hints")
+	}
+}
+
+func TestQuery_JoinHintWithoutJoin(t *testing.T) {
+	q := NewQuery().
+		Select("*").
+		From("users").
+		JoinHint("roles", ForceIndex, "idx1")
+
+	if _, _, err := (&MySQLTranscriber{}).Transcribe(q); err == nil {
+		t.Error("expected a hint without a matching join to fail transcription")
+	}
+
+	composed := NewQuery().ComposeWith(q)
+	if _, _, err := (&MySQLTranscriber{}).Transcribe(composed); err == nil {
+		t.Error("expected composed queries to keep the hint error")
+	}
+}
@@ -0,0 +1,97 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"reflect"
+	"strings"
+)
+
+// SaveRow inserts an entity whose primary key is zero. Otherwise it looks the key up in a transaction, locking the
+// row, and updates the row like UpdateRowRef when it exists or inserts it like InsertRowRef when it does not, so only
+// the hooks, generated keys and timestamps of what it did apply. The returned bool reports whether the row was
+// inserted. With InnoDB, concurrent saves of the same new key both lock the gap of the key and deadlock on their
+// inserts. SaveRow then restarts its transaction, trying up to three times
+func SaveRow[T IEntity](db *sql.DB, entity *T) (*Result, bool, error) {
+	return SaveRowContext(context.Background(), db, entity)
+}
+
+// SaveRowContext saves a row like SaveRow, passing ctx to the statements and hooks. When ctx carries a transaction
+// from WithTx the lookup and the write run in it, and as a deadlock rolls that transaction back, retrying is left
+// to the caller
+func SaveRowContext[T IEntity](ctx context.Context, db *sql.DB, entity *T) (*Result, bool, error) {
+	if reflect.ValueOf(*entity).IsZero() {
+		panic("Cannot save zero entity " + reflect.TypeOf(*entity).String())
+	}
+
+	meta := getEntityMeta(typeOf[T]())
+	mustGetTable(entity)
+	v := reflect.ValueOf(entity).Elem()
+
+	keyed := false
+	for _, pk := range meta.PrimaryKeys {
+		if !v.Field(pk.Index).IsZero() {
+			keyed = true
+		}
+	}
+
+	_, inCallerTx := ctx.Value(txKey{}).(*sql.Tx)
+
+	for attempt := 1; ; attempt++ {
+		r, inserted, deadlocked, err := saveRow(ctx, db, meta, entity, keyed, !inCallerTx && attempt < saveAttempts)
+		if !deadlocked {
+			return r, inserted, err
+		}
+	}
+}
+
+const saveAttempts = 3
+
+// saveRow runs one attempt of SaveRow, reporting a deadlock instead of panicking when retry is set
+func saveRow[T IEntity](ctx context.Context, db *sql.DB, meta *entityMeta, entity *T, keyed bool, retry bool) (r *Result, inserted bool, deadlocked bool, err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			if !retry || !isDeadlock(p) {
+				panic(p)
+			}
+			deadlocked = true
+		}
+	}()
+
+	err = inTx(ctx, db, func(ex executor) error {
+		var err error
+
+		if keyed && rowExists(ex, meta, entityKey(meta, entityToMap(entity, false, false, false))) {
+			r, err = updateRow(ex, entity)
+			return err
+		}
+
+		inserted = true
+		r, err = insertRow(ex, entity, false)
+		return err
+	})
+
+	return r, inserted && err == nil, false, err
+}
+
+// isDeadlock reports whether a panic is a statement that MySQL rolled back as a deadlock victim
+func isDeadlock(p any) bool {
+	var e *statementError
+	err, ok := p.(error)
+	return ok && errors.As(err, &e) && strings.Contains(e.err.Error(), "Error 1213")
+}
+
+// rowExists reports whether the row of a key exists, locking it or the gap it would be inserted in until the
+// transaction of ex ends
+func rowExists(ex executor, meta *entityMeta, key map[string]any) bool {
+	q, args, err := whereKey(NewQuery().Select(meta.PrimaryKeys[0].Column).From(meta.Table), meta, key, "").Transcribe(ex.db)
+	if err != nil {
+		panic(err)
+	}
+
+	rows := ex.Query(Raw(q+" FOR UPDATE", args...))
+	defer rows.Close()
+
+	return rows.Next()
+}
@@ -7,6 +7,8 @@ import (
 
 type Relation interface {
 	getChildrenQuery(id any) *QueryBuilder
+	getChildrenInQuery(ids []any) *QueryBuilder
+	childrenParentKey() string
 	joinParentsQuery() *QueryBuilder
 	assignChildren(db *sql.DB, parentId string, childPk string, childIds []string, subtractive bool) error
 	setChildren(db *sql.DB, parentId string, childPk string, childEntities []map[string]any, subtractive bool) error
@@ -25,6 +27,14 @@ func (r ManyToOneDef) getChildrenQuery(_ any) *QueryBuilder {
 	panic("ManyToOne " + r.child() + " -> " + r.parent() + " relation does not have children")
 }
 
+func (r ManyToOneDef) getChildrenInQuery(_ []any) *QueryBuilder {
+	panic("ManyToOne " + r.child() + " -> " + r.parent() + " relation does not have children")
+}
+
+func (r ManyToOneDef) childrenParentKey() string {
+	panic("ManyToOne " + r.child() + " -> " + r.parent() + " relation does not have children")
+}
+
 func (r ManyToOneDef) joinParentsQuery() *QueryBuilder {
 	return NewQuery().
 		AddField(
@@ -92,6 +102,26 @@ func (r OneToManyDef) getChildrenQuery(id any) *QueryBuilder {
 		)
 }
 
+func (r OneToManyDef) getChildrenInQuery(ids []any) *QueryBuilder {
+	return NewQuery().
+		AddField(
+			TableField(r.parent(), "*"),
+		).
+		LeftJoinEq(
+			r.parent(),
+			Ident(r.parentKey()),
+			Ident(r.childKey()),
+		).
+		WhereIn(
+			Ident(r.childKey()),
+			ids,
+		)
+}
+
+func (r OneToManyDef) childrenParentKey() string {
+	return r.childKey()
+}
+
 func (r OneToManyDef) joinParentsQuery() *QueryBuilder {
 	panic("OneToMany " + r.child() + " -> " + r.parent() + " relation does not have parents")
 }
@@ -337,6 +367,32 @@ func (r ManyToManyDef) getChildrenQuery(id any) *QueryBuilder {
 		)
 }
 
+func (r ManyToManyDef) getChildrenInQuery(ids []any) *QueryBuilder {
+	return NewQuery().
+		Select(
+			TableField(r.ThroughTable, "*"),
+			TableField(r.parent(), "*"),
+		).
+		LeftJoinEq(
+			r.ThroughTable,
+			Ident(r.ThroughToKey),
+			Ident(r.childKey()),
+		).
+		LeftJoinEq(
+			r.parent(),
+			Ident(r.ThroughFromKey),
+			r.parentKey(),
+		).
+		WhereIn(
+			Ident(r.ThroughFromKey),
+			ids,
+		)
+}
+
+func (r ManyToManyDef) childrenParentKey() string {
+	return r.ThroughFromKey
+}
+
 func (r ManyToManyDef) joinParentsQuery() *QueryBuilder {
 	panic("ManyToMany " + r.parent() + " <-> " + r.child() + " relation does not have a single Parent")
 }
@@ -0,0 +1,78 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"reflect"
+)
+
+// ForceDelete deletes the row of an entity even when it is soft deleted
+func ForceDelete[T IEntity](db *sql.DB, entity T) (*Result, error) {
+	return ForceDeleteContext(context.Background(), db, entity)
+}
+
+func ForceDeleteContext[T IEntity](ctx context.Context, db *sql.DB, entity T) (*Result, error) {
+	return deleteRowIn(ctx, db, &entity, true)
+}
+
+// Restore clears the soft delete column of an entity's row through the update path, so its timestamps, hooks and
+// pending changes apply as they do for UpdateRow. Versioned entities must still be at the same version
+func Restore[T IEntity](db *sql.DB, entity T) (*Result, error) {
+	return RestoreRef(db, &entity)
+}
+
+// RestoreRef restores a row like Restore and writes the new version of a versioned entity back to it
+func RestoreRef[T IEntity](db *sql.DB, entity *T) (*Result, error) {
+	return RestoreRefContext(context.Background(), db, entity)
+}
+
+// RestoreRefContext restores a row like RestoreRef, passing ctx to the statements and hooks. When ctx carries a
+// transaction from WithTx the update runs in it
+func RestoreRefContext[T IEntity](ctx context.Context, db *sql.DB, entity *T) (*Result, error) {
+	if reflect.ValueOf(*entity).IsZero() {
+		panic("Cannot restore zero entity " + reflect.TypeOf(*entity).String())
+	}
+
+	meta := getEntityMeta(typeOf[T]())
+	if meta.SoftDelete == "" {
+		panic(meta.Type.String() + " does not have a softdelete tag")
+	}
+
+	for _, f := range meta.Fields {
+		if f.Column == meta.SoftDelete {
+			v := reflect.ValueOf(entity).Elem().Field(f.Index)
+			v.Set(reflect.Zero(v.Type()))
+		}
+	}
+
+	var r *Result
+
+	err := inTx(ctx, db, func(ex executor) error {
+		u, err := newRowUpdate(ex, entity, map[string]any{meta.SoftDelete: nil})
+		if err != nil {
+			return err
+		}
+
+		r, err = u.exec(ex)
+		return err
+	})
+
+	return r, err
+}
+
+// scopeTrashed filters out the soft deleted rows of entities with a softdelete tag, unless the query includes them
+func scopeTrashed(q *QueryBuilder, t reflect.Type, table string) {
+	meta := getEntityMeta(t)
+	if meta.SoftDelete == "" {
+		return
+	}
+
+	column := TableField(table, meta.SoftDelete)
+
+	switch q.Trashed {
+	case TrashedExclude:
+		q.WhereIsNull(column)
+	case TrashedOnly:
+		q.WhereIsNotNull(column)
+	}
+}
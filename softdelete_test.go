@@ -0,0 +1,41 @@
+package db
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestScopeTrashed(t *testing.T) {
+	tests := []struct {
+		query    *QueryBuilder
+		expected string
+	}{
+		{NewQuery(), "SELECT documents.* FROM documents WHERE documents.document_deleted_at IS NULL"},
+		{NewQuery().WithTrashed(), "SELECT documents.* FROM documents"},
+		{NewQuery().OnlyTrashed(), "SELECT documents.* FROM documents WHERE documents.document_deleted_at IS NOT NULL"},
+	}
+
+	for _, test := range tests {
+		q := NewQuery().
+			Select(TableField("documents", "*")).
+			From("documents").
+			ComposeWith(test.query)
+
+		scopeTrashed(q, reflect.TypeOf(Document{}), "documents")
+
+		s, _, err := (&MySQLTranscriber{}).Transcribe(q)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if s != test.expected {
+			t.Errorf("expected %q, got %q", test.expected, s)
+		}
+	}
+
+	q := NewQuery().From("children")
+	scopeTrashed(q, reflect.TypeOf(Child{}), "children")
+	if len(q.WhereCondition.Conditions) != 0 {
+		t.Error("entities without a softdelete tag should not be scoped")
+	}
+}
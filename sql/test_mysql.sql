@@ -34,6 +34,32 @@ VALUES
 UNLOCK TABLES;
 
 
+# Dump of table documents
+# ------------------------------------------------------------
+
+DROP TABLE IF EXISTS `documents`;
+
+CREATE TABLE `documents` (
+  `document_id` int(11) unsigned NOT NULL AUTO_INCREMENT,
+  `document_title` varchar(50) NOT NULL,
+  `document_version` int(11) unsigned NOT NULL DEFAULT '1',
+  `document_deleted_at` datetime DEFAULT NULL,
+  `document_created` datetime DEFAULT NULL,
+  `document_updated` datetime(3) DEFAULT NULL,
+  PRIMARY KEY (`document_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8;
+
+LOCK TABLES `documents` WRITE;
+/*!40000 ALTER TABLE `documents` DISABLE KEYS */;
+
+INSERT INTO `documents` (`document_id`, `document_title`, `document_version`)
+VALUES
+	(1,'Document 1',1);
+
+/*!40000 ALTER TABLE `documents` ENABLE KEYS */;
+UNLOCK TABLES;
+
+
 # Dump of table friends
 # ------------------------------------------------------------
 
@@ -57,6 +83,28 @@ VALUES
 UNLOCK TABLES;
 
 
+# Dump of table ledger
+# ------------------------------------------------------------
+
+DROP TABLE IF EXISTS `ledger`;
+
+CREATE TABLE `ledger` (
+  `ledger_id` int(11) unsigned NOT NULL AUTO_INCREMENT,
+  `ledger_amount` decimal(30,10) NOT NULL,
+  PRIMARY KEY (`ledger_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8;
+
+LOCK TABLES `ledger` WRITE;
+/*!40000 ALTER TABLE `ledger` DISABLE KEYS */;
+
+INSERT INTO `ledger` (`ledger_id`, `ledger_amount`)
+VALUES
+	(1,12345678901234567890.0123456789);
+
+/*!40000 ALTER TABLE `ledger` ENABLE KEYS */;
+UNLOCK TABLES;
+
+
 # Dump of table parent_friends
 # ------------------------------------------------------------
 
@@ -86,6 +134,20 @@ VALUES
 UNLOCK TABLES;
 
 
+# Dump of table tokens
+# ------------------------------------------------------------
+
+DROP TABLE IF EXISTS `tokens`;
+
+CREATE TABLE `tokens` (
+  `token_id` binary(16) NOT NULL,
+  `token_ref` char(36) NOT NULL,
+  `token_name` varchar(50) NOT NULL,
+  `token_secret` varbinary(255) DEFAULT NULL,
+  PRIMARY KEY (`token_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8;
+
+
 # Dump of table parents
 # ------------------------------------------------------------
 
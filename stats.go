@@ -0,0 +1,201 @@
+package db
+
+import (
+	"encoding/json"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+type StatementStats struct {
+	Fingerprint  string        `json:"fingerprint"`
+	Calls        uint64        `json:"calls"`
+	Errors       uint64        `json:"errors"`
+	TotalLatency time.Duration `json:"total_latency_ns"`
+	MaxLatency   time.Duration `json:"max_latency_ns"`
+	RowsReturned uint64        `json:"rows_returned"`
+	RowsAffected uint64        `json:"rows_affected"`
+}
+
+func (s StatementStats) AvgLatency() time.Duration {
+	if s.Calls == 0 {
+		return 0
+	}
+
+	return s.TotalLatency / time.Duration(s.Calls)
+}
+
+// StatsCollector aggregates execution statistics per statement fingerprint
+type StatsCollector struct {
+	mu    sync.Mutex
+	stats map[string]*StatementStats
+}
+
+func NewStatsCollector() *StatsCollector {
+	return &StatsCollector{
+		stats: make(map[string]*StatementStats),
+	}
+}
+
+func (c *StatsCollector) get(fingerprint string) *StatementStats {
+	s, ok := c.stats[fingerprint]
+
+	if !ok {
+		s = &StatementStats{Fingerprint: fingerprint}
+		c.stats[fingerprint] = s
+	}
+
+	return s
+}
+
+func (c *StatsCollector) Record(query string, latency time.Duration, rowsAffected int64, err error) {
+	f := Fingerprint(query)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	s := c.get(f)
+	s.Calls++
+	s.TotalLatency += latency
+
+	if latency > s.MaxLatency {
+		s.MaxLatency = latency
+	}
+
+	if err != nil {
+		s.Errors++
+	}
+
+	if rowsAffected > 0 {
+		s.RowsAffected += uint64(rowsAffected)
+	}
+}
+
+func (c *StatsCollector) RecordRows(query string, rows uint64) {
+	f := Fingerprint(query)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.get(f).RowsReturned += rows
+}
+
+// Snapshot returns a copy of the collected statistics, ordered by total latency
+func (c *StatsCollector) Snapshot() []StatementStats {
+	c.mu.Lock()
+	out := make([]StatementStats, 0, len(c.stats))
+	for _, s := range c.stats {
+		out = append(out, *s)
+	}
+	c.mu.Unlock()
+
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].TotalLatency == out[j].TotalLatency {
+			return out[i].Fingerprint < out[j].Fingerprint
+		}
+		return out[i].TotalLatency > out[j].TotalLatency
+	})
+
+	return out
+}
+
+func (c *StatsCollector) Reset() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.stats = make(map[string]*StatementStats)
+}
+
+func (c *StatsCollector) MarshalJSON() ([]byte, error) {
+	return json.Marshal(c.Snapshot())
+}
+
+var statsCollector atomic.Pointer[StatsCollector]
+
+// SetStatsCollector enables statement statistics for every Query and Exec, pass nil to disable. It is safe to call
+// while queries run
+func SetStatsCollector(c *StatsCollector) {
+	statsCollector.Store(c)
+}
+
+func statsEnabled() bool {
+	return statsCollector.Load() != nil
+}
+
+func recordStats(query string, start time.Time, rowsAffected int64, err error) {
+	if c := statsCollector.Load(); c != nil {
+		c.Record(query, time.Since(start), rowsAffected, err)
+	}
+}
+
+func recordRows(query string, rows uint64) {
+	if c := statsCollector.Load(); c != nil {
+		c.RecordRows(query, rows)
+	}
+}
+
+var (
+	fingerprintNumber = regexp.MustCompile(`(?i)(^|[^\w.$])-?(0x[0-9a-f]+|\d+(\.\d+)?(e[+-]?\d+)?|\.\d+)`)
+	fingerprintInList = regexp.MustCompile(`(?i)\b(NOT\s+)?IN\s*\(\s*\?(\s*,\s*\?)*\s*\)`)
+	fingerprintValues = regexp.MustCompile(`(?i)\bVALUES\s*\([\s?,]*\)(\s*,\s*\([\s?,]*\))*`)
+)
+
+// Fingerprint normalises a statement so that queries differing only in their literal values share a key:
+// string and numeric literals become ?, IN lists and VALUES rows are collapsed and whitespace is normalised
+func Fingerprint(sql string) string {
+	var b strings.Builder
+	runes := []rune(sql)
+
+	for i := 0; i < len(runes); i++ {
+		ch := runes[i]
+
+		switch {
+		case ch == '\'' || ch == '"':
+			quote := ch
+			for i++; i < len(runes); i++ {
+				if runes[i] == '\\' {
+					i++
+				} else if runes[i] == quote {
+					if i+1 < len(runes) && runes[i+1] == quote {
+						i++
+					} else {
+						break
+					}
+				}
+			}
+			b.WriteRune('?')
+		case ch == '`':
+			b.WriteRune(ch)
+			for i++; i < len(runes) && runes[i] != '`'; i++ {
+				b.WriteRune(runes[i])
+			}
+			b.WriteRune('`')
+		case ch == '/' && i+1 < len(runes) && runes[i+1] == '*' && (i+2 >= len(runes) || runes[i+2] != '+'):
+			for i += 2; i+1 < len(runes) && !(runes[i] == '*' && runes[i+1] == '/'); i++ {
+			}
+			i++
+			b.WriteRune(' ')
+		case ch == '#' || (ch == '-' && i+1 < len(runes) && runes[i+1] == '-'):
+			for ; i < len(runes) && runes[i] != '\n'; i++ {
+			}
+			b.WriteRune(' ')
+		default:
+			b.WriteRune(ch)
+		}
+	}
+
+	s := fingerprintNumber.ReplaceAllString(b.String(), "$1?")
+	s = normalizeSql(s)
+	s = fingerprintInList.ReplaceAllStringFunc(s, func(m string) string {
+		if strings.HasPrefix(strings.ToUpper(m), "NOT") {
+			return "NOT IN(...)"
+		}
+		return "IN(...)"
+	})
+	s = fingerprintValues.ReplaceAllString(s, "VALUES (...)")
+
+	return s
+}
@@ -0,0 +1,97 @@
+package db
+
+import (
+	"encoding/json"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestFingerprint(t *testing.T) {
+	cases := map[string]string{
+		"SELECT * FROM users WHERE id = 5":                                 "SELECT * FROM users WHERE id = ?",
+		"SELECT *  FROM users\n WHERE name = 'O\\'Brien' AND age > -1.5e3": "SELECT * FROM users WHERE name = ? AND age > ?",
+		"SELECT * FROM users WHERE id IN(1, 2, 3)":                         "SELECT * FROM users WHERE id IN(...)",
+		"SELECT * FROM users WHERE id NOT IN (?, ?)":                       "SELECT * FROM users WHERE id NOT IN(...)",
+		"SELECT t1.col2 FROM t1 /* comment */ WHERE x = \"y\"":             "SELECT t1.col2 FROM t1 WHERE x = ?",
+		"SELECT /*+ MAX_EXECUTION_TIME(1000) */ * FROM `table1`":           "SELECT /*+ MAX_EXECUTION_TIME(?) */ * FROM `table1`",
+		"INSERT INTO t (a, b) VALUES (1, 'x'), (2, 'y')":                   "INSERT INTO t (a, b) VALUES (...)",
+	}
+
+	for in, expected := range cases {
+		if actual := Fingerprint(in); actual != expected {
+			t.Errorf("Fingerprint(%q) = %q, expected %q", in, actual, expected)
+		}
+	}
+}
+
+func TestStatsCollector(t *testing.T) {
+	c := NewStatsCollector()
+
+	c.Record("SELECT * FROM users WHERE id = 1", 2*time.Millisecond, 0, nil)
+	c.Record("SELECT * FROM users WHERE id = 2", 4*time.Millisecond, 0, errors.New("failed"))
+	c.RecordRows("SELECT * FROM users WHERE id = 2", 3)
+	c.Record("UPDATE users SET name = 'x'", time.Millisecond, 7, nil)
+
+	s := c.Snapshot()
+
+	if len(s) != 2 {
+		t.Fatalf("expected 2 fingerprints, got %d", len(s))
+	}
+
+	if s[0].Calls != 2 || s[0].Errors != 1 || s[0].RowsReturned != 3 {
+		t.Errorf("invalid select stats %+v", s[0])
+	}
+
+	if s[0].MaxLatency != 4*time.Millisecond || s[0].AvgLatency() != 3*time.Millisecond {
+		t.Errorf("invalid select latency %+v", s[0])
+	}
+
+	if s[1].RowsAffected != 7 {
+		t.Errorf("invalid update stats %+v", s[1])
+	}
+
+	j, err := json.Marshal(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var decoded []StatementStats
+	err = json.Unmarshal(j, &decoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(decoded) != 2 || decoded[0].Fingerprint != s[0].Fingerprint {
+		t.Errorf("invalid JSON snapshot %s", j)
+	}
+
+	c.Reset()
+	if len(c.Snapshot()) != 0 {
+		t.Error("Reset() did not clear stats")
+	}
+}
+
+func TestSetStatsCollector_Concurrent(t *testing.T) {
+	defer SetStatsCollector(nil)
+
+	var wg sync.WaitGroup
+	c := NewStatsCollector()
+
+	for i := 0; i < 8; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			SetStatsCollector(c)
+			SetStatsCollector(nil)
+		}()
+		go func() {
+			defer wg.Done()
+			recordStats("SELECT 1", time.Now(), 0, nil)
+			recordRows("SELECT 1", 1)
+		}()
+	}
+
+	wg.Wait()
+}
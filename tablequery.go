@@ -1,10 +1,13 @@
 package db
 
 import (
+	"context"
 	"database/sql"
-	"fmt"
+	"database/sql/driver"
 	"reflect"
 	"slices"
+	"strings"
+	"sync"
 )
 
 type IDType interface {
@@ -28,27 +31,17 @@ func GetRows[T IEntity](db *sql.DB, qs ...*QueryBuilder) *Rows[T] {
 		Select(TableField(table, "*")).
 		From(table)
 
-	for _, r := range getManyToOneRelations(db, table) {
-		q.LeftJoinEq(
-			r.parent(),
-			Ident(r.parentKey()),
-			Ident(r.childKey()),
-		).AddField(TableField(r.parent(), "*"))
-	}
+	joinParents(db, q, typeOf[T](), table, "")
+	selectComputed(q, typeOf[T]())
 
 	q.ComposeWith(qs...)
+	scopeTrashed(q, typeOf[T](), table)
 
 	return Query[T](db, q)
 }
 
 func GetRowById[T IEntity, I IDType](db *sql.DB, id I, qs ...*QueryBuilder) (T, bool) {
-	s := new(T)
-	pk := mustGetPrimaryKeyField(s)
-	table := getPrimaryKeyTable(pk)
-	pkField := TableField(table, pk.Tag.Get("field"))
-
-	r := getTableRowsByValue(db, table, string(pkField), id, qs...)
-	return As[T](r).Row()
+	return GetRowByKey[T](db, id, qs...)
 }
 
 func GetCount[T IEntity](db *sql.DB, qs ...*QueryBuilder) uint {
@@ -59,35 +52,21 @@ func GetCount[T IEntity](db *sql.DB, qs ...*QueryBuilder) uint {
 	query := NewQuery().
 		From(table)
 
-	for _, r := range getManyToOneRelations(db, table) {
-		query.LeftJoinEq(
-			r.parent(),
-			Ident(r.parentKey()),
-			Ident(r.childKey()),
-		).AddField(TableField(r.parent(), "*"))
-	}
+	joinParents(db, query, typeOf[T](), table, "")
+
+	query.ComposeWith(qs...)
+	scopeTrashed(query, typeOf[T](), table)
 
-	query.ComposeWith(qs...).
-		ClearFields().
+	query.ClearFields().
 		ClearOrderBys().
 		Select(Raw("COUNT(*) AS count"))
 
-	transcriber := getTranscriber(db.Driver())
-	q, args, err := transcriber.Transcribe(query)
-	if err != nil {
-		panic(err)
-	}
-
-	rows, err := db.Query(q, args...)
-	if err != nil {
-		m := fmt.Sprintf("%s: \"%s\" args: %v", err, q, args)
-		panic(m)
-	}
+	rows := queryStd(db, query)
 	defer func() { _ = rows.Close() }()
 
 	var count uint
 	rows.Next()
-	err = rows.Scan(&count)
+	err := rows.Scan(&count)
 	if err != nil {
 		panic(err)
 	}
@@ -95,90 +74,369 @@ func GetCount[T IEntity](db *sql.DB, qs ...*QueryBuilder) uint {
 	return count
 }
 
+// InsertRow inserts the row of an entity, running the insert and its hooks in one transaction
 func InsertRow[T IEntity](db *sql.DB, entity T) (*Result, error) {
-	if reflect.ValueOf(entity).IsZero() {
-		panic("Cannot insert zero entity " + reflect.TypeOf(entity).String())
+	return InsertRowRefContext(context.Background(), db, &entity, false)
+}
+
+// InsertRowContext inserts a row like InsertRow, passing ctx to the statements and hooks. When ctx carries a
+// transaction from WithTx the insert runs in it
+func InsertRowContext[T IEntity](ctx context.Context, db *sql.DB, entity T) (*Result, error) {
+	return InsertRowRefContext(ctx, db, &entity, false)
+}
+
+// InsertRowRef inserts a row like InsertRow and writes the generated or auto increment primary key back to the entity.
+// With refresh the inserted row is read back into the entity and its snapshot, so defaulted and generated columns are
+// set, in the same statement with RETURNING when the transcriber supports it
+func InsertRowRef[T IEntity](db *sql.DB, entity *T, refresh bool) (*Result, error) {
+	return InsertRowRefContext(context.Background(), db, entity, refresh)
+}
+
+func InsertRowRefContext[T IEntity](ctx context.Context, db *sql.DB, entity *T, refresh bool) (*Result, error) {
+	var r *Result
+
+	err := inTx(ctx, db, func(ex executor) error {
+		var err error
+		r, err = insertRow(ex, entity, refresh)
+		return err
+	})
+
+	return r, err
+}
+
+func insertRow[T IEntity](ex executor, entity *T, refresh bool) (*Result, error) {
+	if reflect.ValueOf(*entity).IsZero() {
+		panic("Cannot insert zero entity " + reflect.TypeOf(*entity).String())
 	}
 
 	pk := mustGetPrimaryKeyField(entity)
 	table := getPrimaryKeyTable(pk)
 
-	fields, err := doFilterInsert[T](&entity)
+	fields, err := insertFields(ex, table, entity)
 	if err != nil {
 		return nil, err
 	}
-	fields = filterTableFields(db, table, fields)
+
+	q := NewQuery().
+		InsertInto(table).
+		Set(fields)
+
+	var r *Result
+
+	if t, ok := getTranscriber(ex.db.Driver()).(ReturningTranscriber); ok && refresh && t.SupportsReturning() {
+		r = insertReturning(ex, q.Returning("*"), pk, entity)
+	} else {
+		r = ex.Exec(q)
+		if id, err := r.LastInsertId(); err == nil && len(getEntityMeta(typeOf[T]()).PrimaryKeys) == 1 {
+			setInsertId(reflect.ValueOf(entity).Elem().FieldByIndex(pk.Index), id)
+		}
+
+		if refresh {
+			meta := getEntityMeta(typeOf[T]())
+			key := entityKey(meta, entityToMap(entity, false, false, false))
+
+			rows := getTableRowsByKey(ex, meta.Type, table, key, NewQuery().WithTrashed())
+			if e, ok := As[T](rows).Row(); ok {
+				*entity = e
+			}
+		}
+	}
+
+	return r, callHook(ex, entity, IAfterInsert.AfterInsert)
+}
+
+// insertFields generates the keys and timestamps of an entity, runs its BeforeInsert hook and returns its filtered
+// columns
+func insertFields[T IEntity](ex executor, table string, entity *T) (map[string]any, error) {
+	generateKeys(entity)
+	setTimestamps(ex.db, table, entity, Insert)
+
+	if err := callHook(ex, entity, IBeforeInsert.BeforeInsert); err != nil {
+		return nil, err
+	}
+
+	fields, err := doFilterInsert[T](entity)
+	if err != nil {
+		return nil, err
+	}
+	fields = filterTableFields(ex.db, table, fields)
 
 	if len(fields) == 0 {
 		panic("no fields to insert")
 	}
 
-	q := NewQuery().
-		InsertInto(table).
-		Set(fields)
+	return fields, nil
+}
 
-	return Exec(db, q), nil
+func insertReturning[T IEntity](ex executor, q *QueryBuilder, pk reflect.StructField, entity *T) *Result {
+	s, args, err := q.Transcribe(ex.db)
+	if err != nil {
+		panic(err)
+	}
+
+	e, ok := As[T](ex.Query(q)).Row()
+	if !ok {
+		panic("no row returned by " + s)
+	}
+	*entity = e
+
+	var id int64
+	if v := reflect.ValueOf(e).FieldByIndex(pk.Index); v.CanInt() {
+		id = v.Int()
+	} else if v.CanUint() {
+		id = int64(v.Uint())
+	}
+
+	return &Result{returningResult{id}, s, args}
+}
+
+// returningResult is the result of an insert that returned its row, which the driver reports no result for
+type returningResult struct {
+	id int64
+}
+
+func (r returningResult) LastInsertId() (int64, error) {
+	return r.id, nil
+}
+
+func (r returningResult) RowsAffected() (int64, error) {
+	return 1, nil
 }
 
+// setInsertId sets a zero integer primary key to the auto increment id of an insert
+func setInsertId(pk reflect.Value, id int64) {
+	if !pk.IsZero() || !(pk.CanInt() || pk.CanUint()) || id <= 0 {
+		return
+	}
+
+	if pk.CanInt() {
+		pk.SetInt(id)
+	} else {
+		pk.SetUint(uint64(id))
+	}
+}
+
+// UpdateRow updates the row of an entity, running the update and its hooks in one transaction
 func UpdateRow[T IEntity](db *sql.DB, entity T) (*Result, error) {
-	if reflect.ValueOf(entity).IsZero() {
-		panic("Cannot insert zero entity " + reflect.TypeOf(entity).String())
+	return UpdateRowRefContext(context.Background(), db, &entity)
+}
+
+// UpdateRowContext updates a row like UpdateRow, passing ctx to the statements and hooks. When ctx carries a
+// transaction from WithTx the update runs in it
+func UpdateRowContext[T IEntity](ctx context.Context, db *sql.DB, entity T) (*Result, error) {
+	return UpdateRowRefContext(ctx, db, &entity)
+}
+
+// UpdateRowRef updates a row like UpdateRow and writes the new version of a versioned entity back to it.
+// Entities with a field tagged version:"true" are only updated if the row still has the same version, otherwise
+// ErrStaleEntity is returned. Hydrated entities without changes are not written, their result affects no rows and
+// their hooks do not run
+func UpdateRowRef[T IEntity](db *sql.DB, entity *T) (*Result, error) {
+	return UpdateRowRefContext(context.Background(), db, entity)
+}
+
+func UpdateRowRefContext[T IEntity](ctx context.Context, db *sql.DB, entity *T) (*Result, error) {
+	var r *Result
+
+	err := inTx(ctx, db, func(ex executor) error {
+		var err error
+		r, err = updateRow(ex, entity)
+		return err
+	})
+
+	return r, err
+}
+
+func updateRow[T IEntity](ex executor, entity *T) (*Result, error) {
+	u, err := newRowUpdate(ex, entity, nil)
+	if err != nil || u == nil {
+		return &Result{Result: driver.RowsAffected(0)}, err
+	}
+
+	return u.exec(ex)
+}
+
+// rowUpdate is the update of one entity, which is applied to the entity once the update succeeded
+type rowUpdate[T IEntity] struct {
+	entity      *T
+	meta        *entityMeta
+	table       string
+	fields      map[string]any
+	key         map[string]any
+	version     reflect.Value
+	nextVersion reflect.Value
+}
+
+// newRowUpdate prepares the update of an entity's changed fields and the set columns, which are always written. It
+// returns nil for a hydrated entity without changes when there are no set columns
+func newRowUpdate[T IEntity](ex executor, entity *T, set map[string]any) (*rowUpdate[T], error) {
+	if reflect.ValueOf(*entity).IsZero() {
+		panic("Cannot insert zero entity " + reflect.TypeOf(*entity).String())
+	}
+
+	if changes, tracked := entityChanges(entity); tracked && len(changes) == 0 && len(set) == 0 {
+		return nil, nil
 	}
 
 	pk := mustGetPrimaryKeyField(entity)
 	table := getPrimaryKeyTable(pk)
 
-	fields, err := doFilterUpdate[T](&entity)
+	setTimestamps(ex.db, table, entity, Update)
+
+	if err := callHook(ex, entity, IBeforeUpdate.BeforeUpdate); err != nil {
+		return nil, err
+	}
+
+	fields, err := doFilterUpdate[T](entity)
 	if err != nil {
 		return nil, err
 	}
-	fields = filterTableFields(db, table, fields)
+	fields = filterTableFields(ex.db, table, fields)
+
+	for k, v := range set {
+		fields[k] = v
+	}
 
 	if len(fields) == 0 {
 		panic("no fields to update")
 	}
 
-	pkFieldName := pk.Tag.Get("field")
-	pkFieldValue, hasPkField := fields[pkFieldName]
-	if !hasPkField {
-		panic("No primary key value " + pkFieldName + "provided")
+	meta := getEntityMeta(typeOf[T]())
+	u := &rowUpdate[T]{
+		entity: entity,
+		meta:   meta,
+		table:  table,
+		fields: fields,
+		key:    entityKey(meta, fields),
+	}
+
+	if meta.HasVersion {
+		u.version = reflect.ValueOf(entity).Elem().Field(meta.Version.Index)
+		u.nextVersion = incrementVersion(u.version, tableColumnPrecision(ex.db, table, meta.Version.Column))
+		fields[meta.Version.Column] = u.nextVersion.Interface()
 	}
 
+	return u, nil
+}
+
+func (u *rowUpdate[T]) query() *QueryBuilder {
 	q := NewQuery().
-		Update(table).
-		Set(fields).
-		WhereEq(pkFieldName, pkFieldValue)
+		Update(u.table).
+		Set(u.fields)
+
+	whereKey(q, u.meta, u.key, "")
 
-	return Exec(db, q), nil
+	if u.meta.HasVersion {
+		q.WhereEq(u.meta.Version.Column, u.version.Interface())
+	}
+
+	return q
 }
 
+func (u *rowUpdate[T]) exec(ex executor) (*Result, error) {
+	r := ex.Exec(u.query())
+
+	if err := u.check(r); err != nil {
+		return r, err
+	}
+	u.apply()
+
+	return r, u.after(ex)
+}
+
+func (u *rowUpdate[T]) check(r *Result) error {
+	if u.meta.HasVersion {
+		return checkVersion(r, u.table, keyValue(u.meta, u.key), u.version)
+	}
+	return nil
+}
+
+func (u *rowUpdate[T]) apply() {
+	if u.meta.HasVersion {
+		u.version.Set(u.nextVersion)
+	}
+
+	commitChanges(u.entity, u.fields)
+}
+
+func (u *rowUpdate[T]) after(ex executor) error {
+	return callHook(ex, u.entity, IAfterUpdate.AfterUpdate)
+}
+
+// DeleteRow deletes the row of an entity, or sets the column named by its softdelete tag to the current time. The
+// delete and its hooks run in one transaction
 func DeleteRow[T IEntity](db *sql.DB, entity T) (*Result, error) {
-	if reflect.ValueOf(entity).IsZero() {
-		panic("Cannot delete zero entity " + reflect.TypeOf(entity).String())
+	return DeleteRowContext(context.Background(), db, entity)
+}
+
+// DeleteRowContext deletes a row like DeleteRow, passing ctx to the statements and hooks. When ctx carries a
+// transaction from WithTx the delete runs in it
+func DeleteRowContext[T IEntity](ctx context.Context, db *sql.DB, entity T) (*Result, error) {
+	return deleteRowIn(ctx, db, &entity, getEntityMeta(typeOf[T]()).SoftDelete == "")
+}
+
+func deleteRowIn[T IEntity](ctx context.Context, db *sql.DB, entity *T, force bool) (*Result, error) {
+	var r *Result
+
+	err := inTx(ctx, db, func(ex executor) error {
+		var err error
+		r, err = deleteRow(ex, entity, force)
+		return err
+	})
+
+	return r, err
+}
+
+func deleteRow[T IEntity](ex executor, entity *T, force bool) (*Result, error) {
+	if reflect.ValueOf(*entity).IsZero() {
+		panic("Cannot delete zero entity " + reflect.TypeOf(*entity).String())
 	}
 
-	pk := mustGetPrimaryKeyField(entity)
-	table := getPrimaryKeyTable(pk)
-	fields := entityToMap(&entity, false, false, false)
+	if err := callHook(ex, entity, IBeforeDelete.BeforeDelete); err != nil {
+		return nil, err
+	}
 
-	pkFieldName := pk.Tag.Get("field")
-	pkFieldValue, hasPkField := fields[pkFieldName]
+	meta := getEntityMeta(typeOf[T]())
+	key := entityKey(meta, entityToMap(entity, false, false, false))
+	q := deleteQuery(ex.db, meta, force)
 
-	if !hasPkField {
-		panic("No primary key value " + pkFieldName + "provided")
+	whereKey(q, meta, key, "")
+
+	if meta.HasVersion {
+		version := reflect.ValueOf(entity).Elem().Field(meta.Version.Index)
+		q.WhereEq(meta.Version.Column, version.Interface())
 	}
 
-	q := NewQuery().
-		DeleteFrom(table).
-		WhereEq(pkFieldName, pkFieldValue)
+	r := ex.Exec(q)
 
-	return Exec(db, q), nil
+	if meta.HasVersion {
+		version := reflect.ValueOf(entity).Elem().Field(meta.Version.Index)
+		if err := checkVersion(r, meta.Table, keyValue(meta, key), version); err != nil {
+			return r, err
+		}
+	}
+
+	return r, callHook(ex, entity, IAfterDelete.AfterDelete)
+}
+
+// deleteQuery returns a delete of the entity's table, or the update that soft deletes its rows
+func deleteQuery(db *sql.DB, meta *entityMeta, force bool) *QueryBuilder {
+	if force {
+		return NewQuery().DeleteFrom(meta.Table)
+	}
+
+	return NewQuery().
+		Update(meta.Table).
+		Set(map[string]any{meta.SoftDelete: columnTime(db, meta.Table, meta.SoftDelete)})
 }
 
 func GetChildren[Parent IEntity, Children IEntity, I IDType](db *sql.DB, id I, queries ...*QueryBuilder) *Rows[Children] {
 	var p Parent
+	return getChildren[Children](db, mustGetTable(&p), id, queries...)
+}
+
+func getChildren[Children IEntity](db *sql.DB, pt string, id any, queries ...*QueryBuilder) *Rows[Children] {
 	var c Children
-	pt := mustGetTable(&p)
 	ct := mustGetTable(&c)
 
 	relation := getRelation(db, pt, ct)
@@ -191,17 +449,11 @@ func GetChildren[Parent IEntity, Children IEntity, I IDType](db *sql.DB, id I, q
 		From(ct).
 		ComposeWith(relation.getChildrenQuery(id))
 
-	for _, r := range getManyToOneRelations(db, ct) {
-		if r.parent() != pt {
-			q.LeftJoinEq(
-				r.parent(),
-				Ident(r.parentKey()),
-				Ident(r.childKey()),
-			).AddField(TableField(r.parent(), "*"))
-		}
-	}
+	joinParents(db, q, typeOf[Children](), ct, pt)
+	selectComputed(q, typeOf[Children]())
 
 	q.ComposeWith(queries...)
+	scopeTrashed(q, typeOf[Children](), ct)
 
 	return Query[Children](db, q)
 }
@@ -258,33 +510,58 @@ func SetChildren[Parent IEntity, Children IEntity, S EntitySet[Children]](db *sq
 }
 
 func GetTableFields(db *sql.DB, table string) []string {
-	if fs, ok := _tableFields[table]; ok {
+	_tableFieldsMu.RLock()
+	fs, ok := _tableFields[table]
+	_tableFieldsMu.RUnlock()
+
+	if ok {
 		return fs
 	}
 
-	query := NewQuery().Select("*").From(table).WhereEq(1, 0)
-	transcriber := getTranscriber(db.Driver())
-	q, args, err := transcriber.Transcribe(query)
-	if err != nil {
-		panic(err)
-	}
+	rows := queryStd(db, NewQuery().Select("*").From(table).WhereEq(1, 0))
+	defer func() { _ = rows.Close() }()
 
-	rows, err := db.Query(q, args...)
+	columns, err := rows.Columns()
 	if err != nil {
-		m := fmt.Sprintf("Failed fetching fields from %s: %s: \"%s\" args: %v", table, err, q, args)
-		panic(m)
+		panic(err)
 	}
 
-	columns, err := rows.Columns()
+	columnTypes, err := rows.ColumnTypes()
 	if err != nil {
 		panic(err)
 	}
+
+	_tableFieldsMu.Lock()
 	_tableFields[table] = columns
+	_tableColumnTypes[table] = columnTypes
+	_tableFieldsMu.Unlock()
 
 	return columns
 }
 
+// _tableFieldsMu guards the column caches, which are filled lazily by concurrent queries
+var _tableFieldsMu sync.RWMutex
 var _tableFields = make(map[string][]string)
+var _tableColumnTypes = make(map[string][]*sql.ColumnType)
+
+// tableColumnPrecision returns the fractional second precision of a time column, 0 when it is unknown
+func tableColumnPrecision(db *sql.DB, table string, column string) int64 {
+	GetTableFields(db, table)
+
+	_tableFieldsMu.RLock()
+	columnTypes := _tableColumnTypes[table]
+	_tableFieldsMu.RUnlock()
+
+	for _, ct := range columnTypes {
+		if ct.Name() == column {
+			if precision, _, ok := ct.DecimalSize(); ok {
+				return precision
+			}
+		}
+	}
+
+	return 0
+}
 
 func tableHasField(db *sql.DB, table string, field string) bool {
 	fields := GetTableFields(db, table)
@@ -327,23 +604,8 @@ func getField(v reflect.Value, name string, readOnly bool, recurse bool) (reflec
 		panic("Can only get field of struct")
 	}
 
-	for i := 0; i < v.NumField(); i++ {
-		if (t.Field(i).Tag.Get("field") == name && t.Field(i).Tag.Get("foreign") == "") ||
-			(readOnly && t.Field(i).Tag.Get("computed") == name) {
-			return v.Field(i).Addr(), true
-		}
-	}
-
-	if !recurse {
-		return reflect.Zero(t), false
-	}
-
-	for i := 0; i < v.NumField(); i++ {
-		if t.Field(i).Type.Kind() == reflect.Struct {
-			if fieldRef, found := getField(v.Field(i).Addr(), name, readOnly, recurse); found {
-				return fieldRef, true
-			}
-		}
+	if index, found := getEntityMeta(t).field(name, readOnly, recurse); found {
+		return v.FieldByIndex(index).Addr(), true
 	}
 
 	return reflect.Zero(t), false
@@ -364,22 +626,8 @@ func getPrimaryKeyField(entity any) (reflect.StructField, bool) {
 }
 
 func getValuePrimaryKeyField(entity reflect.Value) (reflect.StructField, bool) {
-	v := entity
-	t := v.Type()
-
-	if v.Kind() == reflect.Pointer {
-		v = v.Elem()
-		t = t.Elem()
-	}
-
-	for i := 0; i < v.NumField(); i++ {
-		field := t.Field(i)
-		if getPrimaryKeyTable(field) != "" {
-			return field, true
-		}
-	}
-
-	return reflect.StructField{}, false
+	m := getEntityMeta(entity.Type())
+	return m.PrimaryKey, m.HasPrimaryKey
 }
 
 func mustGetPrimaryKeyField(entity any) reflect.StructField {
@@ -421,21 +669,85 @@ func mustGetTable(entity any) string {
 	return table
 }
 
-func getTableRowsByValue(db *sql.DB, table string, field string, value any, qs ...*QueryBuilder) *sql.Rows {
+func getTableRowsByKey(ex executor, t reflect.Type, table string, key map[string]any, qs ...*QueryBuilder) *sql.Rows {
 	q := NewQuery().
-		From(table).
-		WhereEq(field, value).
+		From(table)
+
+	whereKey(q, getEntityMeta(t), key, table).
 		ComposeWith(qs...)
 
+	joinParents(ex.db, q, t, table, "")
+	scopeTrashed(q, t, table)
+
+	q.Select(TableField(table, "*"))
+	selectComputed(q, t)
+
+	return ex.Query(q)
+}
+
+const columnPrefixSeparator = "__"
+
+// joinParents left joins the entities of the foreign fields of t, and the ManyToOne parents of table that are not
+// mapped to a field. Columns of mapped parents are prefixed with the field path, such as Parent__parent_name, so
+// they hydrate into the right nested struct, columns of unmapped parents keep their names. A table joined more
+// than once is aliased as <table>_<field path> after its first occurrence. Parents in the exclude table are not
+// joined, as the query already joins it
+func joinParents(db *sql.DB, q *QueryBuilder, t reflect.Type, table string, exclude string) {
+	used := map[string]bool{table: true}
+	joined := make(map[string]bool)
+	joinForeignFields(db, q, t, table, "", exclude, used, joined)
+
 	for _, r := range getManyToOneRelations(db, table) {
-		q.LeftJoinEq(
-			r.parent(),
-			Ident(r.parentKey()),
-			Ident(r.childKey()),
-		).AddField(TableField(r.parent(), "*"))
+		if r.parent() != exclude && !joined[r.parent()] {
+			alias := joinParent(db, q, r.parent(), unqualified(r.parentKey()), table, unqualified(r.childKey()), r.parent(), used)
+			q.AddField(TableField(alias, "*"))
+		}
 	}
+}
 
-	q.Select(TableField(table, "*"))
+func joinForeignFields(db *sql.DB, q *QueryBuilder, t reflect.Type, alias string, path string, exclude string, used map[string]bool, joined map[string]bool) {
+	for _, f := range getEntityMeta(t).Fields {
+		if f.Foreign == "" || f.Field.Type.Kind() != reflect.Struct || f.Foreign == exclude {
+			continue
+		}
+
+		pk, has := getPrimaryKeyField(reflect.New(f.Field.Type).Interface())
+		if !has {
+			continue
+		}
+
+		if path == "" {
+			joined[f.Foreign] = true
+		}
+
+		fieldPath := path + f.Field.Name
+		parentAlias := joinParent(db, q, f.Foreign, pk.Tag.Get("field"), alias, f.Column, fieldPath, used)
+		for _, column := range GetTableFields(db, f.Foreign) {
+			q.AddField(Ident(string(TableField(parentAlias, column)) + " AS " + fieldPath + columnPrefixSeparator + column))
+		}
+		joinForeignFields(db, q, f.Field.Type, parentAlias, fieldPath+columnPrefixSeparator, exclude, used, joined)
+	}
+}
+
+func joinParent(db *sql.DB, q *QueryBuilder, table string, key string, childAlias string, childKey string, path string, used map[string]bool) string {
+	alias := table
+	join := Ident(table)
+
+	if used[table] {
+		alias = table + "_" + path
+		join = Ident(table + " AS " + alias)
+	}
+	used[alias] = true
+
+	q.LeftJoinEq(
+		join,
+		TableField(alias, key),
+		TableField(childAlias, childKey),
+	)
+
+	return alias
+}
 
-	return queryStd(db, q)
+func unqualified(column string) string {
+	return column[strings.LastIndex(column, ".")+1:]
 }
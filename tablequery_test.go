@@ -1,8 +1,12 @@
 package db
 
 import (
+	"bytes"
+	"context"
+	"errors"
 	"fmt"
 	"reflect"
+	"strings"
 	"testing"
 	"time"
 )
@@ -89,6 +93,624 @@ func TestUpdateRow(t *testing.T) {
 	}
 }
 
+func TestUpdateRowVersion(t *testing.T) {
+	db := DB()
+	first, has := GetRowById[Document](db, 1)
+	if !has {
+		t.Fatal("could not get document")
+	}
+	second, _ := GetRowById[Document](db, 1)
+
+	first.Title = fmt.Sprintf("%d", time.Now().UnixNano())
+	_, err := UpdateRowRef(db, &first)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if first.Version != second.Version+1 {
+		t.Errorf("expected version %d, got %d", second.Version+1, first.Version)
+	}
+
+	second.Title = "Stale"
+	_, err = UpdateRow(db, second)
+	if !errors.Is(err, ErrStaleEntity) {
+		t.Errorf("expected ErrStaleEntity, got %v", err)
+	}
+
+	_, err = DeleteRow(db, second)
+	if !errors.Is(err, ErrStaleEntity) {
+		t.Errorf("expected ErrStaleEntity, got %v", err)
+	}
+
+	if d, _ := GetRowById[Document](db, 1); d.Title != first.Title {
+		t.Error("stale entity overwrote the row")
+	}
+}
+
+func TestInsertRowRef(t *testing.T) {
+	db := DB()
+	d := Document{Title: "Inserted"}
+
+	_, err := InsertRowRef(db, &d, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if d.ID == 0 {
+		t.Fatal("expected the auto increment id to be set")
+	}
+
+	r := Document{Title: "Refreshed"}
+	_, err = InsertRowRef(db, &r, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if r.ID != d.ID+1 || r.Version != 1 {
+		t.Errorf("expected id %d at version 1, got %d at version %d", d.ID+1, r.ID, r.Version)
+	}
+
+	if IsChanged(r) {
+		t.Errorf("refreshed entity should be clean, got %v", Changes(r))
+	}
+}
+
+func TestSetInsertId(t *testing.T) {
+	var id int64
+	setInsertId(reflect.ValueOf(&id).Elem(), 42)
+	if id != 42 {
+		t.Errorf("expected id 42, got %d", id)
+	}
+
+	var uid uint = 7
+	setInsertId(reflect.ValueOf(&uid).Elem(), 42)
+	if uid != 7 {
+		t.Errorf("a set id should not be overwritten, got %d", uid)
+	}
+
+	var sid string
+	setInsertId(reflect.ValueOf(&sid).Elem(), 42)
+	if sid != "" {
+		t.Errorf("string ids should not be set, got %q", sid)
+	}
+}
+
+func TestCompositeKey(t *testing.T) {
+	db := DB()
+
+	pf, has := GetRowByKey[ParentFriend](db, []any{1, 2})
+	if !has {
+		t.Fatal("could not get parent friend")
+	}
+
+	if pf.Status != "bad" {
+		t.Errorf("expected status bad, got %s", pf.Status)
+	}
+
+	pf.Status = "good"
+	_, err := UpdateRow(db, pf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pf, _ = GetRowByKey[ParentFriend](db, ParentFriend{ParentID: 1, FriendID: 2})
+	if pf.Status != "good" {
+		t.Errorf("expected status good, got %s", pf.Status)
+	}
+
+	if other, _ := GetRowByKey[ParentFriend](db, []any{1, 1}); other.Status != "good" {
+		t.Errorf("update should only change its own row")
+	}
+
+	_, err = DeleteRow(db, pf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, has = GetRowByKey[ParentFriend](db, map[string]any{"parent_id": 1, "friend_id": 2}); has {
+		t.Error("deleted parent friend should not be found")
+	}
+
+	_, err = InsertRow(db, ParentFriend{ParentID: 1, FriendID: 2, Status: "bad"})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestGeneratedKeys(t *testing.T) {
+	db := DB()
+	token := Token{Name: "Generated"}
+
+	_, err := InsertRowRef(db, &token, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if token.ID == (BinaryUUID{}) || token.Ref == (UUID{}) {
+		t.Fatal("expected the keys to be generated")
+	}
+
+	found, has := GetRowByKey[Token](db, token.ID)
+	if !has {
+		t.Fatal("could not get token by its generated key")
+	}
+
+	if found.ID != token.ID || found.Ref != token.Ref || found.Name != token.Name {
+		t.Errorf("expected %v, got %v", token, found)
+	}
+}
+
+func TestBatchRows(t *testing.T) {
+	db := DB()
+	documents := []Document{{Title: "Batch 1"}, {Title: "Batch 2"}, {Title: "Batch 3"}}
+
+	_, err := InsertRows(db, documents, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i, d := range documents {
+		if d.ID != 0 {
+			t.Errorf("document %d should not have an inferred auto increment id, got %d", i, d.ID)
+		}
+	}
+
+	documents = GetRows[Document](db, NewQuery().
+		WhereIn("document_title", []any{"Batch 1", "Batch 2", "Batch 3"}).
+		OrderBy("document_id", Asc)).
+		Slice()
+
+	if len(documents) != 3 || documents[0].Title != "Batch 1" || documents[2].Title != "Batch 3" {
+		t.Fatalf("expected the 3 inserted documents, got %v", documents)
+	}
+
+	documents[0].Title = "Batch 1 updated"
+	documents[1].Title = "Batch 2 updated"
+	stale := documents[2]
+	documents[2].Title = "Batch 3 updated"
+
+	_, err = UpdateRows(db, documents, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	friend, has := GetRows[Friend](db).Row()
+	if !has {
+		t.Fatal("could not get a friend")
+	}
+
+	// the existing friend is written unchanged, which MySQL does not count as affected
+	missing := []Friend{{ID: friend.ID, Name: friend.Name}, {ID: -1, Name: "Missing"}, {ID: -2, Name: "Missing"}}
+	_, err = UpdateRows(db, missing, false)
+	var missingErr *BatchError
+	if !errors.As(err, &missingErr) || len(missingErr.Errors) != 2 || !errors.Is(missingErr.Errors[1], ErrEntityNotFound) {
+		t.Errorf("expected the missing friends to be reported, got %v", err)
+	}
+
+	for _, d := range documents {
+		if found, _ := GetRowById[Document](db, d.ID); found.Title != d.Title || found.Version != 2 {
+			t.Errorf("expected %s at version 2, got %s at version %d", d.Title, found.Title, found.Version)
+		}
+	}
+
+	_, err = DeleteRows(db, []Document{documents[0], stale}, false)
+	var batchErr *BatchError
+	if !errors.As(err, &batchErr) || !errors.Is(batchErr.Errors[1], ErrStaleEntity) || len(batchErr.Errors) != 1 {
+		t.Fatalf("expected the stale document to be reported, got %v", err)
+	}
+
+	if _, has := GetRowById[Document](db, documents[0].ID); has {
+		t.Error("deleted document should not be found")
+	}
+
+	_, err = ForceDelete(db, documents[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSaveRow(t *testing.T) {
+	db := DB()
+	token := Token{Name: "Saved"}
+
+	_, inserted, err := SaveRow(db, &token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !inserted || token.ID == (BinaryUUID{}) {
+		t.Fatal("expected a token without a key to be inserted")
+	}
+
+	token.Name = "Saved again"
+	_, inserted, err = SaveRow(db, &token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if inserted {
+		t.Error("expected a token with an existing key to be updated")
+	}
+
+	if found, _ := GetRowByKey[Token](db, token.ID); found.Name != "Saved again" {
+		t.Errorf("expected the saved name, got %s", found.Name)
+	}
+
+	_, inserted, err = SaveRow(db, &token)
+	if err != nil || inserted {
+		t.Errorf("expected an unchanged token to be saved as an update, got %v, %v", inserted, err)
+	}
+
+	upserted := Token{ID: BinaryUUID(NewUUIDv4()), Ref: NewUUIDv4(), Name: "Upserted"}
+	_, inserted, err = SaveRow(db, &upserted)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !inserted {
+		t.Error("expected a token with a new key to be inserted")
+	}
+
+	hooked, has := GetRowByKey[HookedToken](db, upserted.ID)
+	if !has {
+		t.Fatal("could not get the saved token")
+	}
+
+	ref := hooked.Ref
+	hooked.Events = nil
+	hooked.Name = "Saved with hooks"
+	_, inserted, err = SaveRow(db, &hooked)
+	if err != nil || inserted {
+		t.Fatalf("expected an existing token to be updated, got %v, %v", inserted, err)
+	}
+	if len(hooked.Events) != 0 || hooked.Ref != ref {
+		t.Errorf("expected no insert hooks or generated keys on update, got %v and ref %v", hooked.Events, hooked.Ref)
+	}
+}
+
+func TestSaveRow_Deadlock(t *testing.T) {
+	deadlock := &statementError{err: errors.New("Error 1213 (40001): Deadlock found when trying to get lock; try restarting transaction")}
+	duplicate := &statementError{err: errors.New("Error 1062 (23000): Duplicate entry '1' for key 'PRIMARY'")}
+
+	if !isDeadlock(deadlock) || isDeadlock(duplicate) || isDeadlock(deadlock.Error()) {
+		t.Error("only failed statements of deadlock victims should be retried")
+	}
+}
+
+func TestComputedColumns(t *testing.T) {
+	db := DB()
+
+	friend, has := GetRowById[LabeledFriend](db, 1)
+	if !has || friend.Label != "#1 Friend 1" {
+		t.Fatalf("expected the computed label of friend 1, got %q", friend.Label)
+	}
+
+	friends := GetRows[LabeledFriend](db, NewQuery().OrderBy("friend_id", Asc)).Slice()
+	if len(friends) < 2 || friends[1].Label != "#2 Friend 2" {
+		t.Errorf("expected computed labels on every row, got %v", friends)
+	}
+
+	friend.Name = "Renamed"
+	friend.Label = "ignored"
+	if _, err := UpdateRow(db, friend); err != nil {
+		t.Fatal(err)
+	}
+
+	friend, _ = GetRowById[LabeledFriend](db, 1)
+	if friend.Label != "#1 Renamed" {
+		t.Errorf("expected the label to be recomputed, got %q", friend.Label)
+	}
+
+	friend.Name = "Friend 1"
+	if _, err := UpdateRow(db, friend); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestNamingConvention(t *testing.T) {
+	db := DB()
+
+	friend, has := GetRowById[NamedFriend](db, 2)
+	if !has || friend.Name != "Friend 2" {
+		t.Fatalf("expected friend 2 to be mapped by convention, got %+v", friend)
+	}
+
+	inserted := NamedFriend{Name: "Named", Visits: 3}
+	if _, err := InsertRowRef(db, &inserted, false); err != nil {
+		t.Fatal(err)
+	}
+
+	found, has := GetRowById[NamedFriend](db, inserted.ID)
+	if !has || found.Name != "Named" || found.Visits != 0 {
+		t.Errorf("unexpected convention mapped row %+v", found)
+	}
+
+	if _, err := DeleteRow(db, found); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestEnumColumns(t *testing.T) {
+	db := DB()
+
+	pf, has := GetRowByKey[RatedParentFriend](db, []any{1, 1})
+	if !has || pf.Status.Wrapped != "good" {
+		t.Fatalf("expected a good parent friend, got %+v", pf)
+	}
+
+	pf.Status = NewEnum[friendStatus]("ugly")
+	if _, err := UpdateRow(db, pf); !errors.Is(err, ErrInvalidEnum) {
+		t.Errorf("expected undeclared enum values to be rejected, got %v", err)
+	}
+
+	pf.Status = NewEnum[friendStatus]("bad")
+	if _, err := UpdateRow(db, pf); err != nil {
+		t.Fatal(err)
+	}
+
+	pf, _ = GetRowByKey[RatedParentFriend](db, []any{1, 1})
+	if pf.Status.Wrapped != "bad" {
+		t.Errorf("expected status bad, got %s", pf.Status.Wrapped)
+	}
+
+	pf.Status = NewEnum[friendStatus]("good")
+	if _, err := UpdateRow(db, pf); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestEncryptedColumns(t *testing.T) {
+	db := DB()
+	SetKeyProvider(StaticKeys{Current: "k1", Keys: map[string][]byte{"k1": make([]byte, 32)}})
+	defer SetKeyProvider(nil)
+
+	token := SecretToken{Name: "secret", Secret: NewDeterministic("4111 1111 1111 1111")}
+	if _, err := InsertRowRef(db, &token, false); err != nil {
+		t.Fatal(err)
+	}
+
+	var raw []byte
+	row := db.QueryRow("SELECT token_secret FROM tokens WHERE token_id = ?", token.ID)
+	if err := row.Scan(&raw); err != nil {
+		t.Fatal(err)
+	}
+
+	if bytes.Contains(raw, []byte("4111")) {
+		t.Error("the plaintext should not be stored")
+	}
+
+	found, has := GetRows[SecretToken](db, NewQuery().WhereEq("token_secret", NewDeterministic("4111 1111 1111 1111"))).Row()
+	if !has || found.ID != token.ID {
+		t.Fatal("expected the token to be found by its deterministic secret")
+	}
+
+	if secret, _ := found.Secret.Get(); secret != "4111 1111 1111 1111" || found.Secret.KeyID() != "k1" {
+		t.Errorf("unexpected secret %q with key %s", secret, found.Secret.KeyID())
+	}
+
+	SetKeyProvider(StaticKeys{Current: "k2", Keys: map[string][]byte{"k1": make([]byte, 32), "k2": bytes.Repeat([]byte{1}, 32)}})
+
+	found.Name = "rotated"
+	if _, err := UpdateRow(db, found); err != nil {
+		t.Fatal(err)
+	}
+
+	found, _ = GetRowByKey[SecretToken](db, token.ID)
+	if secret, _ := found.Secret.Get(); secret != "4111 1111 1111 1111" || found.Secret.KeyID() != "k2" {
+		t.Errorf("expected the secret to be rotated to k2, got %q with key %s", secret, found.Secret.KeyID())
+	}
+
+	if _, err := DeleteRow(db, found); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDecimalColumns(t *testing.T) {
+	db := DB()
+
+	entry, has := GetRowById[LedgerEntry](db, 1)
+	if !has || entry.Amount.String() != "12345678901234567890.0123456789" {
+		t.Fatalf("expected the amount to be scanned exactly, got %s", entry.Amount)
+	}
+
+	inserted := LedgerEntry{Amount: entry.Amount.Add(MustDecimal("0.0000000001"))}
+	if _, err := InsertRowRef(db, &inserted, false); err != nil {
+		t.Fatal(err)
+	}
+
+	found, has := GetRows[LedgerEntry](db, NewQuery().WhereEq("ledger_amount", MustDecimal("12345678901234567890.012345679"))).Row()
+	if !has || found.ID != inserted.ID || found.Amount.String() != "12345678901234567890.0123456790" {
+		t.Errorf("expected the inserted amount to round-trip, got %s", found.Amount)
+	}
+
+	if _, err := DeleteRow(db, found); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestInsertRowContext_WithTx(t *testing.T) {
+	db := DB()
+
+	tx, err := db.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	token := HookedToken{Name: "rolled back"}
+	if _, err = InsertRowRefContext(WithTx(context.Background(), tx), db, &token, false); err != nil {
+		t.Fatal(err)
+	}
+
+	if err = tx.Rollback(); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, has := GetRowByKey[HookedToken](db, token.ID); has {
+		t.Error("the insert and its audit hook should be rolled back with the caller's transaction")
+	}
+}
+
+func TestLifecycleHooks(t *testing.T) {
+	db := DB()
+	token := HookedToken{Name: "kept"}
+
+	_, err := InsertRowRef(db, &token, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(token.Events, []string{"BeforeInsert", "AfterInsert"}) {
+		t.Errorf("unexpected insert hooks %v", token.Events)
+	}
+
+	found, has := GetRowByKey[HookedToken](db, token.ID)
+	if !has || found.Name != "kept audited" {
+		t.Fatalf("expected the AfterInsert hook to write its audit, got %q", found.Name)
+	}
+
+	if !reflect.DeepEqual(found.Events, []string{"AfterLoad"}) {
+		t.Errorf("unexpected load hooks %v", found.Events)
+	}
+
+	_, err = DeleteRow(db, found)
+	if !errors.Is(err, errHookRejected) {
+		t.Errorf("expected the BeforeDelete hook to abort the delete, got %v", err)
+	}
+
+	if _, has = GetRowByKey[HookedToken](db, token.ID); !has {
+		t.Error("aborted delete should keep the row")
+	}
+
+	batch := []HookedToken{{Name: "batched"}, {Name: "rejected"}}
+	_, err = InsertRows(db, batch, true)
+	if !errors.Is(err, errHookRejected) {
+		t.Fatalf("expected the batch to be rejected, got %v", err)
+	}
+
+	if _, has = GetRowByKey[HookedToken](db, batch[0].ID); has {
+		t.Error("rejected transactional batch should not insert any row")
+	}
+}
+
+func TestSoftDelete(t *testing.T) {
+	db := DB()
+	r, err := InsertRow(db, Document{Title: "Trash"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	id, _ := r.LastInsertId()
+
+	d, has := GetRowById[Document](db, id)
+	if !has {
+		t.Fatal("could not get document")
+	}
+
+	_, err = DeleteRow(db, d)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, has = GetRowById[Document](db, id); has {
+		t.Error("soft deleted document should not be found")
+	}
+
+	if _, has = GetRowById[Document](db, id, NewQuery().WithTrashed()); !has {
+		t.Error("soft deleted document should be found with trashed rows")
+	}
+
+	trashed := GetCount[Document](db, NewQuery().OnlyTrashed())
+	if trashed != 1 {
+		t.Errorf("expected 1 trashed document, got %d", trashed)
+	}
+
+	version := d.Version
+	_, err = RestoreRef(db, &d)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if d.Version != version+1 {
+		t.Errorf("expected restore to bump the version to %d, got %d", version+1, d.Version)
+	}
+
+	restored, has := GetRowById[Document](db, id)
+	if !has {
+		t.Fatal("restored document should be found")
+	}
+
+	if restored.Version != d.Version || !restored.Updated.Valid {
+		t.Errorf("expected the restored row to have version %d and an update time, got %+v", d.Version, restored)
+	}
+
+	_, err = ForceDelete(db, d)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, has = GetRowById[Document](db, id, NewQuery().WithTrashed()); has {
+		t.Error("force deleted document should not be found")
+	}
+}
+
+func TestTimestamps(t *testing.T) {
+	db := DB()
+	created := time.Date(2024, 3, 1, 12, 30, 15, 123456789, time.UTC)
+	SetClock(func() time.Time { return created })
+	defer SetClock(nil)
+
+	r, err := InsertRow(db, Document{Title: "Stamped"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	id, _ := r.LastInsertId()
+
+	d, _ := GetRowById[Document](db, id)
+	if !d.Created.Wrapped.Equal(created.Truncate(time.Second)) {
+		t.Errorf("expected created %v, got %v", created.Truncate(time.Second), d.Created.Wrapped)
+	}
+	if !d.Updated.Wrapped.Equal(created.Truncate(time.Millisecond)) {
+		t.Errorf("expected updated %v, got %v", created.Truncate(time.Millisecond), d.Updated.Wrapped)
+	}
+
+	updated := created.Add(time.Hour)
+	SetClock(func() time.Time { return updated })
+
+	d.Title = "Restamped"
+	_, err = UpdateRowRef(db, &d)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	d, _ = GetRowById[Document](db, id)
+	if !d.Created.Wrapped.Equal(created.Truncate(time.Second)) {
+		t.Errorf("created should not change on update, got %v", d.Created.Wrapped)
+	}
+	if !d.Updated.Wrapped.Equal(updated.Truncate(time.Millisecond)) {
+		t.Errorf("expected updated %v, got %v", updated.Truncate(time.Millisecond), d.Updated.Wrapped)
+	}
+}
+
+func TestStats_CountAndTableFields(t *testing.T) {
+	db := DB()
+	c := NewStatsCollector()
+	SetStatsCollector(c)
+	defer SetStatsCollector(nil)
+
+	_tableFieldsMu.Lock()
+	delete(_tableFields, "children")
+	_tableFieldsMu.Unlock()
+
+	GetTableFields(db, "children")
+	GetCount[Parent](db)
+
+	found := map[string]bool{}
+	for _, s := range c.Snapshot() {
+		found["count"] = found["count"] || strings.Contains(s.Fingerprint, "COUNT(*)")
+		found["fields"] = found["fields"] || strings.Contains(s.Fingerprint, "children")
+	}
+
+	if !found["count"] || !found["fields"] {
+		t.Errorf("expected the count and table field queries to be recorded, got %+v", c.Snapshot())
+	}
+}
+
 func TestColumns(t *testing.T) {
 	db := DB()
 	fields := GetTableFields(db, "parents")
@@ -119,6 +741,170 @@ func TestGetRows(t *testing.T) {
 	}
 }
 
+func TestGetRowsJoinTwice(t *testing.T) {
+	db := DB()
+	c, has := GetRowById[ChildWithParents](db, testChildId1)
+	if !has {
+		t.Fatal("could not get child")
+	}
+
+	if c.Parent.ID != testParentId || c.Same.ID != testParentId {
+		t.Errorf("expected both parents to be %d, got %d and %d", testParentId, c.Parent.ID, c.Same.ID)
+	}
+
+	if c.Parent.Name != c.Same.Name || c.Same.Name.Wrapped == "" {
+		t.Errorf("expected both parents to be hydrated, got %v and %v", c.Parent.Name, c.Same.Name)
+	}
+}
+
+type joinUser struct {
+	ID   int64  `field:"user_id" primary:"join_users"`
+	Name string `field:"user_name"`
+}
+
+type joinMessage struct {
+	ID        int64    `field:"message_id" primary:"join_messages"`
+	Sender    joinUser `field:"sender_id" foreign:"join_users"`
+	Recipient joinUser `field:"recipient_id" foreign:"join_users"`
+}
+
+func TestJoinParents(t *testing.T) {
+	_tableFields["join_users"] = []string{"user_id", "user_name"}
+
+	q := NewQuery().Select(TableField("join_messages", "*")).From("join_messages")
+	joinParents(nil, q, typeOf[joinMessage](), "join_messages", "")
+
+	actual, _, err := (&MySQLTranscriber{}).Transcribe(q)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "SELECT join_messages.*, join_users.user_id AS Sender__user_id, join_users.user_name AS Sender__user_name, " +
+		"join_users_Recipient.user_id AS Recipient__user_id, join_users_Recipient.user_name AS Recipient__user_name " +
+		"FROM join_messages " +
+		"LEFT JOIN join_users ON join_users.user_id = join_messages.sender_id " +
+		"LEFT JOIN join_users AS join_users_Recipient ON join_users_Recipient.user_id = join_messages.recipient_id"
+
+	if actual != expected {
+		t.Errorf("%s (actual)\n%s (expected)", actual, expected)
+	}
+}
+
+func TestJoinParents_Unmapped(t *testing.T) {
+	_tableFields["join_users"] = []string{"user_id", "user_name"}
+	DefRelation(nil, ManyToOneDef{"join_messages", "join_messages.room_id", "join_rooms", "join_rooms.room_id"})
+	defer delete(schema, nil)
+
+	q := NewQuery().Select(TableField("join_messages", "*")).From("join_messages")
+	joinParents(nil, q, typeOf[joinMessage](), "join_messages", "")
+
+	actual, _, err := (&MySQLTranscriber{}).Transcribe(q)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(actual, "join_rooms.* FROM") || !strings.Contains(actual, "LEFT JOIN join_rooms ON join_rooms.room_id = join_messages.room_id") {
+		t.Errorf("expected the unmapped parent to be joined with unprefixed columns, got %s", actual)
+	}
+}
+
+func TestGetRowsWith(t *testing.T) {
+	db := DB()
+	r := GetRows[ParentWithRelations](db, NewQuery().WhereEq("parents.parent_id", testParentId)).
+		With("children", "friends").
+		Slice()
+
+	if len(r) != 1 {
+		t.Fatal("could not get parent")
+	}
+
+	expected := GetChildren[Parent, Child](db, testParentId).Count()
+	if uint(len(r[0].Children)) != expected {
+		t.Errorf("expected %d children, got %d", expected, len(r[0].Children))
+	}
+
+	for _, c := range r[0].Children {
+		if c.Parent.ID != testParentId {
+			t.Errorf("child %d has parent %d", c.ID, c.Parent.ID)
+		}
+	}
+
+	expected = GetChildren[Parent, Friend](db, testParentId).Count()
+	if uint(len(r[0].Friends)) != expected {
+		t.Errorf("expected %d friends, got %d", expected, len(r[0].Friends))
+	}
+}
+
+func TestLazyRelations(t *testing.T) {
+	db := DB()
+	p, has := GetRowById[LazyParent](db, testParentId)
+	if !has {
+		t.Fatal("could not get parent")
+	}
+
+	expected := GetChildren[Parent, Child](db, testParentId).Count()
+	if uint(len(p.Children.Get(db))) != expected || !p.Children.Loaded() {
+		t.Errorf("expected %d children", expected)
+	}
+
+	expected = GetChildren[Parent, Friend](db, testParentId).Count()
+	if uint(len(p.Friends.Get(db))) != expected {
+		t.Errorf("expected %d friends", expected)
+	}
+
+	c, has := GetRowById[LazyChild](db, testChildId1)
+	if !has {
+		t.Fatal("could not get child")
+	}
+
+	parent, has := c.Parent.Get(db)
+	if !has || parent.ID != testParentId {
+		t.Error("could not get parent of child")
+	}
+}
+
+func TestPreloadRelations(t *testing.T) {
+	db := DB()
+	children := GetRows[LazyChild](db).With("parents").Slice()
+
+	for _, c := range children {
+		if !c.Parent.Loaded() {
+			t.Fatalf("parent of child %d was not preloaded", c.ID)
+		}
+	}
+
+	parents := GetRows[LazyParent](db, NewQuery().WhereEq("parents.parent_id", testParentId)).Slice()
+	Preload(db, parents, "children", "friends")
+
+	if len(parents) != 1 || !parents[0].Children.Loaded() || !parents[0].Friends.Loaded() {
+		t.Fatal("children were not preloaded")
+	}
+}
+
+func TestPreloadRelations_NonPrimaryKey(t *testing.T) {
+	db := DB()
+	relations := schema[db]
+	defer func() { schema[db] = relations }()
+	DefRelation(db, OneToManyDef{"documents", "documents.document_version", "children", "children.parent_id"})
+
+	doc := VersionKeyedDocument{Title: "keyed", Version: testParentId}
+	if _, err := InsertRowRef(db, &doc, false); err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _, _ = DeleteRow(db, doc) }()
+
+	if doc.ID == testParentId {
+		t.Fatal("the document ID should differ from its version")
+	}
+
+	expected := GetChildren[Parent, Child](db, testParentId).Slice()
+	docs := GetRows[VersionKeyedDocument](db, NewQuery().WhereEq("document_id", doc.ID)).With("children").Slice()
+
+	if len(docs) != 1 || len(expected) == 0 || len(docs[0].Children) != len(expected) {
+		t.Fatalf("expected the %d children keyed by the document version, got %v", len(expected), docs)
+	}
+}
+
 func TestGetChildrenOneToMany(t *testing.T) {
 	db := DB()
 	existing, has := GetRows[Child](db).Row()
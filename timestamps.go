@@ -0,0 +1,75 @@
+package db
+
+import (
+	"database/sql"
+	"reflect"
+	"time"
+)
+
+var clock = time.Now
+
+// SetClock replaces the source of the current time used for automatic timestamps, soft deletes and time versions.
+// A nil clock restores time.Now
+func SetClock(c func() time.Time) {
+	if c == nil {
+		c = time.Now
+	}
+	clock = c
+}
+
+// setTimestamps sets the fields tagged autoUpdate:"true" on insert and update, and the zero fields tagged
+// autoCreate:"true" on insert, before the entity is filtered
+func setTimestamps[T IEntity](db *sql.DB, table string, entity *T, queryType QueryType) {
+	meta := getEntityMeta(typeOf[T]())
+	v := reflect.ValueOf(entity).Elem()
+
+	if queryType == Insert {
+		for _, f := range meta.AutoCreate {
+			if v.Field(f.Index).IsZero() {
+				setTimestamp(v.Field(f.Index), columnTime(db, table, f.Column))
+			}
+		}
+	}
+
+	for _, f := range meta.AutoUpdate {
+		setTimestamp(v.Field(f.Index), columnTime(db, table, f.Column))
+	}
+}
+
+// columnTime returns the current time truncated to the precision of a column, so the stored value equals the field
+func columnTime(db *sql.DB, table string, column string) time.Time {
+	precision := tableColumnPrecision(db, table, column)
+	return truncateTime(clock(), precision)
+}
+
+func truncateTime(t time.Time, precision int64) time.Time {
+	return t.Truncate(precisionUnit(precision))
+}
+
+// precisionUnit returns the smallest step of a time column with the given fractional second precision
+func precisionUnit(precision int64) time.Duration {
+	d := time.Second
+	for i := int64(0); i < precision && i < 9; i++ {
+		d /= 10
+	}
+	return d
+}
+
+func setTimestamp(field reflect.Value, t time.Time) {
+	switch {
+	case field.Type() == typeOf[time.Time]():
+		field.Set(reflect.ValueOf(t))
+	case field.Type() == typeOf[*time.Time]():
+		field.Set(reflect.ValueOf(&t))
+	default:
+		s, ok := field.Addr().Interface().(sql.Scanner)
+		if !ok {
+			panic("timestamp field must be a time.Time or a sql.Scanner, got " + field.Type().String())
+		}
+
+		err := s.Scan(t)
+		if err != nil {
+			panic(err)
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package db
+
+import (
+	"testing"
+	"time"
+)
+
+type stampedEntity struct {
+	*Entity
+	ID      int64               `field:"stamped_id" primary:"stamped"`
+	Created time.Time           `field:"stamped_created" autoCreate:"true"`
+	Updated *time.Time          `field:"stamped_updated" autoUpdate:"true"`
+	Checked Nullable[time.Time] `field:"stamped_checked" autoUpdate:"true"`
+	seen    map[string]any
+}
+
+func (e *stampedEntity) FilterInsert(fields map[string]any) error {
+	e.seen = fields
+	return nil
+}
+
+func TestTruncateTime(t *testing.T) {
+	tm := time.Date(2024, 3, 1, 12, 30, 15, 123456789, time.UTC)
+
+	tests := map[int64]time.Time{
+		0: time.Date(2024, 3, 1, 12, 30, 15, 0, time.UTC),
+		3: time.Date(2024, 3, 1, 12, 30, 15, 123000000, time.UTC),
+		6: time.Date(2024, 3, 1, 12, 30, 15, 123456000, time.UTC),
+	}
+
+	for precision, expected := range tests {
+		if got := truncateTime(tm, precision); !got.Equal(expected) {
+			t.Errorf("precision %d: expected %v, got %v", precision, expected, got)
+		}
+	}
+}
+
+func TestSetTimestamps(t *testing.T) {
+	_tableFields["stamped"] = []string{"stamped_id", "stamped_created", "stamped_updated", "stamped_checked"}
+
+	now := time.Date(2024, 3, 1, 12, 30, 15, 123456789, time.UTC)
+	SetClock(func() time.Time { return now })
+	defer SetClock(nil)
+
+	e := stampedEntity{ID: 1}
+	setTimestamps(nil, "stamped", &e, Insert)
+
+	expected := now.Truncate(time.Second)
+	if !e.Created.Equal(expected) || e.Updated == nil || !e.Updated.Equal(expected) {
+		t.Errorf("expected timestamps %v, got %v and %v", expected, e.Created, e.Updated)
+	}
+	if !e.Checked.Valid || !e.Checked.Wrapped.Equal(expected) {
+		t.Errorf("expected nullable timestamp %v, got %v", expected, e.Checked)
+	}
+
+	_, err := doFilterInsert(&e)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e.seen["stamped_created"] != expected {
+		t.Errorf("FilterInsert should see the created timestamp, got %v", e.seen["stamped_created"])
+	}
+
+	now = now.Add(time.Hour)
+	setTimestamps(nil, "stamped", &e, Update)
+
+	if !e.Created.Equal(expected) {
+		t.Errorf("created should not change on update, got %v", e.Created)
+	}
+	if !e.Updated.Equal(now.Truncate(time.Second)) {
+		t.Errorf("expected updated %v, got %v", now.Truncate(time.Second), e.Updated)
+	}
+}
@@ -46,14 +46,29 @@ func RegisterTranscriber(d driver.Driver, t Transcriber) {
 	transcribers[getDriverID(d)] = t
 }
 
+// ReturningTranscriber is implemented by transcribers whose dialect may support INSERT ... RETURNING
+type ReturningTranscriber interface {
+	SupportsReturning() bool
+}
+
 type MySQLTranscriber struct {
 	UsePlaceholders bool
+	// Returning enables INSERT ... RETURNING, which MariaDB supports but MySQL does not
+	Returning bool
+}
+
+func (t MySQLTranscriber) SupportsReturning() bool {
+	return t.Returning
 }
 
 func (t MySQLTranscriber) Transcribe(q *QueryBuilder) (string, []any, error) {
 	sql := ""
 	args := make([]any, 0)
 
+	if q.err != nil {
+		return sql, args, q.err
+	}
+
 	switch q.Type {
 	case Select:
 		return t.processSelectQuery(q)
@@ -228,7 +243,11 @@ func (t MySQLTranscriber) processInsertQuery(q *QueryBuilder) (string, []any, er
 		return "", nil, err
 	}
 
-	err = t.set(q, &lines, &args)
+	if len(q.ValueRows) > 0 {
+		err = t.insertValues(q, &lines, &args)
+	} else {
+		err = t.set(q, &lines, &args)
+	}
 	if err != nil {
 		return "", nil, err
 	}
@@ -246,13 +265,17 @@ func (t MySQLTranscriber) insert(q *QueryBuilder, lines *[]string, args *[]any)
 	if e != nil {
 		return e
 	}
+	h, e := t.processOptimizerHints(q.OptimizerHints)
+	if e != nil {
+		return e
+	}
 	switch q.Type {
 	case Insert:
-		*lines = append(*lines, "INSERT INTO "+s)
+		*lines = append(*lines, "INSERT "+h+"INTO "+s)
 	case InsertUpdate:
-		*lines = append(*lines, "INSERT INTO "+s)
+		*lines = append(*lines, "INSERT "+h+"INTO "+s)
 	case InsertIgnore:
-		*lines = append(*lines, "INSERT IGNORE INTO "+s)
+		*lines = append(*lines, "INSERT "+h+"IGNORE INTO "+s)
 	default:
 		panic("Unreachable")
 	}
@@ -260,8 +283,63 @@ func (t MySQLTranscriber) insert(q *QueryBuilder, lines *[]string, args *[]any)
 	return nil
 }
 
+func (t MySQLTranscriber) insertValues(q *QueryBuilder, lines *[]string, args *[]any) error {
+	columns := valueRowColumns(q.ValueRows)
+	rows := make([]string, 0, len(q.ValueRows))
+
+	for _, row := range q.ValueRows {
+		values := make([]string, 0, len(columns))
+
+		for _, c := range columns {
+			v, has := row[c]
+			if !has {
+				values = append(values, "DEFAULT")
+				continue
+			}
+
+			vs, va, ve := t.processValue(RValue(v))
+			if ve != nil {
+				return ve
+			}
+
+			values = append(values, vs)
+			*args = append(*args, va...)
+		}
+
+		rows = append(rows, "("+strings.Join(values, ", ")+")")
+	}
+
+	*lines = append(*lines, "("+strings.Join(columns, ", ")+") VALUES "+strings.Join(rows, ", "))
+	return nil
+}
+
+// valueRowColumns returns the sorted union of the columns of a multi-row insert
+func valueRowColumns(rows []map[string]any) []string {
+	seen := make(map[string]bool)
+	columns := make([]string, 0)
+
+	for _, row := range rows {
+		for c := range row {
+			if !seen[c] {
+				seen[c] = true
+				columns = append(columns, c)
+			}
+		}
+	}
+	sort.Strings(columns)
+
+	return columns
+}
+
 func (t MySQLTranscriber) insertSuffix(q *QueryBuilder, lines *[]string, args *[]any) error {
-	if q.Type == InsertUpdate {
+	if q.Type == InsertUpdate && len(q.Values) == 0 && len(q.ValueRows) > 0 {
+		updates := make([]string, 0)
+		for _, c := range valueRowColumns(q.ValueRows) {
+			updates = append(updates, c+" = VALUES("+c+")")
+		}
+
+		*lines = append(*lines, "ON DUPLICATE KEY UPDATE "+strings.Join(updates, ", "))
+	} else if q.Type == InsertUpdate {
 		ss, sa, se := t.processSet(q.Values)
 		if se != nil {
 			return se
@@ -270,6 +348,20 @@ func (t MySQLTranscriber) insertSuffix(q *QueryBuilder, lines *[]string, args *[
 		*lines = append(*lines, "ON DUPLICATE KEY UPDATE "+ss)
 		*args = append(*args, sa...)
 	}
+
+	if len(q.Returnings) > 0 {
+		if !t.Returning {
+			return errors.New("RETURNING is not supported by this transcriber")
+		}
+
+		rs, ra, re := t.processValue(q.Returnings)
+		if re != nil {
+			return re
+		}
+
+		*lines = append(*lines, "RETURNING "+rs)
+		*args = append(*args, ra...)
+	}
 	return nil
 }
 
@@ -278,7 +370,15 @@ func (t MySQLTranscriber) update(q *QueryBuilder, lines *[]string, args *[]any)
 	if e != nil {
 		return e
 	}
-	*lines = append(*lines, "UPDATE "+s)
+	h, e := t.processOptimizerHints(q.OptimizerHints)
+	if e != nil {
+		return e
+	}
+	ih, e := t.processIndexHints(q.PrimaryHints)
+	if e != nil {
+		return e
+	}
+	*lines = append(*lines, "UPDATE "+h+s+ih)
 	*args = append(*args, a...)
 	return nil
 }
@@ -288,16 +388,27 @@ func (t MySQLTranscriber) delete(q *QueryBuilder, lines *[]string, args *[]any)
 	if te != nil {
 		return te
 	}
+	h, he := t.processOptimizerHints(q.OptimizerHints)
+	if he != nil {
+		return he
+	}
 	if len(q.Fields) > 0 {
 		fs, fa, fe := t.processValue(q.Fields)
 		if fe != nil {
 			return fe
 		}
-		*lines = append(*lines, "DELETE "+fs+" FROM "+ts)
+		ih, ie := t.processIndexHints(q.PrimaryHints)
+		if ie != nil {
+			return ie
+		}
+		*lines = append(*lines, "DELETE "+h+fs+" FROM "+ts+ih)
 		*args = append(*args, fa...)
 		*args = append(*args, ta...)
 	} else {
-		*lines = append(*lines, "DELETE FROM "+ts)
+		if len(q.PrimaryHints) > 0 {
+			return errors.New("index hints are only supported by multi-table deletes")
+		}
+		*lines = append(*lines, "DELETE "+h+"FROM "+ts)
 		*args = append(*args, ta...)
 	}
 	return nil
@@ -308,7 +419,11 @@ func (t MySQLTranscriber) fields(q *QueryBuilder, lines *[]string, args *[]any)
 	if err != nil {
 		return err
 	}
-	*lines = append(*lines, "SELECT "+s)
+	h, err := t.processOptimizerHints(q.OptimizerHints)
+	if err != nil {
+		return err
+	}
+	*lines = append(*lines, "SELECT "+h+s)
 	*args = append(*args, a...)
 	return nil
 }
@@ -318,7 +433,11 @@ func (t MySQLTranscriber) from(q *QueryBuilder, lines *[]string, args *[]any) er
 	if err != nil {
 		return err
 	}
-	*lines = append(*lines, "FROM "+s)
+	h, err := t.processIndexHints(q.PrimaryHints)
+	if err != nil {
+		return err
+	}
+	*lines = append(*lines, "FROM "+s+h)
 	*args = append(*args, a...)
 	return nil
 }
@@ -518,9 +637,17 @@ func (t MySQLTranscriber) processCondition(condition *ConditionSet) (string, []a
 			if le != nil {
 				return "", nil, le
 			}
-			l := c.Right.(List)
-			if len(l) > 0 {
-				rs, ra, re := t.processValue(RValue(c.Right))
+			l, isList := c.Right.(List)
+			if !isList || len(l) > 0 {
+				var rs string
+				var ra []any
+				var re error
+				if sub, ok := c.Right.(*QueryBuilder); ok {
+					rs, ra, re = t.Transcribe(sub)
+					rs = normalizeSql(rs)
+				} else {
+					rs, ra, re = t.processValue(RValue(c.Right))
+				}
 				if re != nil {
 					return "", nil, re
 				}
@@ -650,12 +777,26 @@ func (t MySQLTranscriber) processValue(value Value) (string, []any, error) {
 		} else {
 			return strconv.Itoa(int(val)), []any{}, nil
 		}
+	case Bool:
+		if t.UsePlaceholders {
+			return "?", []any{bool(val)}, nil
+		} else if val {
+			return "TRUE", []any{}, nil
+		} else {
+			return "FALSE", []any{}, nil
+		}
 	case Float:
 		if t.UsePlaceholders {
 			return "?", []any{float64(val)}, nil
 		} else {
 			return fmt.Sprintf("%f", float64(val)), []any{}, nil
 		}
+	case Decimal:
+		if t.UsePlaceholders {
+			return "?", []any{val.String()}, nil
+		} else {
+			return val.String(), []any{}, nil
+		}
 	case Time:
 		if t.UsePlaceholders {
 			return "?", []any{time.Time(val)}, nil
@@ -708,12 +849,17 @@ func (t MySQLTranscriber) processJoins(joins []Join) (string, []any, error) {
 			return "", nil, te
 		}
 
+		hs, he := t.processIndexHints(j.Hints)
+		if he != nil {
+			return "", nil, he
+		}
+
 		cs, ca, ce := t.processCondition(j.Condition)
 		if ce != nil {
 			return "", nil, ce
 		}
 
-		sqls = append(sqls, string(j.JoinType)+" "+ts+" ON "+cs)
+		sqls = append(sqls, string(j.JoinType)+" "+ts+hs+" ON "+cs)
 		args = append(args, ta...)
 		args = append(args, ca...)
 	}
@@ -721,6 +867,43 @@ func (t MySQLTranscriber) processJoins(joins []Join) (string, []any, error) {
 	return strings.Join(sqls, clauseSeparator), args, nil
 }
 
+func (t MySQLTranscriber) processIndexHints(hints []IndexHint) (string, error) {
+	sql := ""
+
+	for _, h := range hints {
+		switch h.Type {
+		case UseIndex, ForceIndex, IgnoreIndex:
+		default:
+			return "", errors.New("Invalid index hint type '" + string(h.Type) + "'")
+		}
+
+		for _, i := range h.Indexes {
+			err := Ident(i).ValidateSQL()
+			if err != nil {
+				return "", err
+			}
+		}
+
+		sql += " " + string(h.Type) + " (" + strings.Join(h.Indexes, ", ") + ")"
+	}
+
+	return sql, nil
+}
+
+func (t MySQLTranscriber) processOptimizerHints(hints []string) (string, error) {
+	if len(hints) == 0 {
+		return "", nil
+	}
+
+	for _, h := range hints {
+		if strings.Contains(h, "*/") {
+			return "", errors.New("optimizer hints cannot contain '*/'")
+		}
+	}
+
+	return "/*+ " + strings.Join(hints, " ") + " */ ", nil
+}
+
 func (t MySQLTranscriber) processOrderBys(orders []Order) (string, []any, error) {
 	sqls := make([]string, 0)
 	args := make([]any, 0)
@@ -789,11 +972,14 @@ func (t MySQLTranscriber) processSet(values map[string]any) (string, []any, erro
 	return strings.Join(sqls, ", "), args, nil
 }
 
+var (
+	leadingSpace  = regexp.MustCompile("^\\s+")
+	trailingSpace = regexp.MustCompile("\\s+$")
+	innerSpace    = regexp.MustCompile("\\s+")
+)
+
 func normalizeSql(sql string) string {
-	re := regexp.MustCompile("^\\s+")
-	sql = re.ReplaceAllString(sql, "")
-	re = regexp.MustCompile("\\s+$")
-	sql = re.ReplaceAllString(sql, "")
-	re = regexp.MustCompile("\\s+")
-	return re.ReplaceAllString(sql, " ")
+	sql = leadingSpace.ReplaceAllString(sql, "")
+	sql = trailingSpace.ReplaceAllString(sql, "")
+	return innerSpace.ReplaceAllString(sql, " ")
 }
@@ -229,6 +229,74 @@ func TestTranscribeInsertUpdateQuery(t *testing.T) {
 	}
 }
 
+func TestTranscribeInsertRowsQuery(t *testing.T) {
+	transcriber := MySQLTranscriber{UsePlaceholders: true}
+
+	q := NewQuery().
+		InsertInto("users").
+		Set([]map[string]any{
+			{"field1": "value1", "field2": 2},
+			{"field1": "value2"},
+		})
+
+	sql, args, err := transcriber.Transcribe(q)
+	if err != nil {
+		t.Error(err)
+	}
+
+	expectedSql := `INSERT INTO users (field1, field2) VALUES (?, ?), (?, DEFAULT)`
+
+	if normalizeSql(sql) != normalizeSql(expectedSql) {
+		t.Errorf("Failed asserting queries are the same \n%s VS:\n%s", normalizeSql(sql), normalizeSql(expectedSql))
+	}
+
+	if !reflect.DeepEqual(args, []any{"value1", 2, "value2"}) {
+		t.Error("Failed asserting argument sets are the same")
+	}
+
+	q.Type = InsertUpdate
+
+	sql, _, err = transcriber.Transcribe(q)
+	if err != nil {
+		t.Error(err)
+	}
+
+	expectedSql = `INSERT INTO users (field1, field2) VALUES (?, ?), (?, DEFAULT) ON DUPLICATE KEY UPDATE field1 = VALUES(field1), field2 = VALUES(field2)`
+
+	if normalizeSql(sql) != normalizeSql(expectedSql) {
+		t.Errorf("Failed asserting queries are the same \n%s VS:\n%s", normalizeSql(sql), normalizeSql(expectedSql))
+	}
+}
+
+func TestTranscribeInsertReturningQuery(t *testing.T) {
+	transcriber := MySQLTranscriber{UsePlaceholders: true, Returning: true}
+
+	q := NewQuery().
+		InsertInto("users").
+		Set(map[string]any{"field1": "value1"}).
+		Returning("user_id", "created")
+
+	sql, args, err := transcriber.Transcribe(q)
+	if err != nil {
+		t.Error(err)
+	}
+
+	expectedSql := `INSERT INTO users SET field1 = ? RETURNING user_id, created`
+
+	if normalizeSql(sql) != normalizeSql(expectedSql) {
+		t.Errorf("Failed asserting queries are the same \n%s VS:\n%s", normalizeSql(sql), normalizeSql(expectedSql))
+	}
+
+	if !reflect.DeepEqual(args, []any{"value1"}) {
+		t.Error("Failed asserting argument sets are the same")
+	}
+
+	_, _, err = MySQLTranscriber{UsePlaceholders: true}.Transcribe(q)
+	if err == nil {
+		t.Error("expected RETURNING to be rejected without support")
+	}
+}
+
 func TestTranscribeDeleteQuery(t *testing.T) {
 	transcriber := MySQLTranscriber{UsePlaceholders: true}
 
@@ -260,3 +328,87 @@ func TestTranscribeDeleteQuery(t *testing.T) {
 		t.Error("Failed asserting argument sets are the same")
 	}
 }
+
+func TestTranscribeIndexHints(t *testing.T) {
+	transcriber := MySQLTranscriber{UsePlaceholders: true}
+
+	q := NewQuery().
+		Select("*").
+		From("users").
+		ForceIndex("users_created").
+		LeftJoinEq("roles", "users.role_id", "roles.role_id").
+		JoinHint("roles", IgnoreIndex, "roles_name", "roles_type").
+		OptimizerHint("MAX_EXECUTION_TIME(1000)").
+		OptimizerHint("NO_ICP(users)").
+		WhereEq("active", 1)
+
+	sql, args, err := transcriber.Transcribe(q)
+	if err != nil {
+		t.Error(err)
+	}
+
+	expectedSql := `SELECT /*+ MAX_EXECUTION_TIME(1000) NO_ICP(users) */ *
+        FROM users FORCE INDEX (users_created)
+        LEFT JOIN roles IGNORE INDEX (roles_name, roles_type) ON users.role_id = roles.role_id
+        WHERE active = ?`
+
+	if normalizeSql(sql) != normalizeSql(expectedSql) {
+		t.Errorf("Failed asserting queries are the same \n%s VS:\n%s", normalizeSql(sql), normalizeSql(expectedSql))
+	}
+
+	if !reflect.DeepEqual(args, []any{1}) {
+		t.Error("Failed asserting argument sets are the same")
+	}
+}
+
+func TestTranscribeUpdateHints(t *testing.T) {
+	transcriber := MySQLTranscriber{UsePlaceholders: true}
+
+	q := NewQuery().
+		Update("users").
+		UseIndex("PRIMARY").
+		OptimizerHint("BKA(users)").
+		Set(map[string]any{"field1": "value1"}).
+		WhereEq("user_id", 5)
+
+	sql, _, err := transcriber.Transcribe(q)
+	if err != nil {
+		t.Error(err)
+	}
+
+	expectedSql := `UPDATE /*+ BKA(users) */ users USE INDEX (PRIMARY) SET field1 = ? WHERE user_id = ?`
+
+	if normalizeSql(sql) != normalizeSql(expectedSql) {
+		t.Errorf("Failed asserting queries are the same \n%s VS:\n%s", normalizeSql(sql), normalizeSql(expectedSql))
+	}
+}
+
+func TestTranscribeInvalidOptimizerHint(t *testing.T) {
+	transcriber := MySQLTranscriber{}
+
+	q := NewQuery().
+		Select("*").
+		From("users").
+		OptimizerHint("BKA(users) */ DROP TABLE users; /*")
+
+	_, _, err := transcriber.Transcribe(q)
+	if err == nil {
+		t.Error("Optimizer hints containing a comment terminator should be rejected")
+	}
+}
+
+func TestTranscribeDeleteIndexHint(t *testing.T) {
+	q := NewQuery().
+		DeleteFrom("users").
+		UseIndex("idx1").
+		WhereEq("id", 1)
+
+	if _, _, err := (&MySQLTranscriber{}).Transcribe(q); err == nil {
+		t.Error("expected index hints on a single-table delete to fail")
+	}
+
+	q.PrimaryHints = nil
+	if _, _, err := (&MySQLTranscriber{}).Transcribe(q); err != nil {
+		t.Error(err)
+	}
+}
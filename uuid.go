@@ -0,0 +1,120 @@
+package db
+
+import (
+	"crypto/rand"
+	"database/sql/driver"
+	"encoding/hex"
+	"errors"
+	"fmt"
+)
+
+var ErrInvalidUUID = errors.New("invalid uuid")
+
+// UUID is stored as its 36 character text form, in a CHAR(36) column. It scans both the text and the 16 byte form
+type UUID [16]byte
+
+// BinaryUUID is a UUID stored as 16 bytes, in a BINARY(16) column. It scans both the text and the 16 byte form
+type BinaryUUID UUID
+
+func NewUUIDv4() UUID {
+	var u UUID
+	randomBytes(u[:])
+
+	u[6] = u[6]&0x0f | 0x40
+	u[8] = u[8]&0x3f | 0x80
+	return u
+}
+
+// NewUUIDv7 returns a UUID that starts with the clock's unix milliseconds, so the values sort by creation time
+func NewUUIDv7() UUID {
+	var u UUID
+	randomBytes(u[6:])
+
+	ms := uint64(clock().UnixMilli())
+	for i := 0; i < 6; i++ {
+		u[i] = byte(ms >> (40 - 8*i))
+	}
+
+	u[6] = u[6]&0x0f | 0x70
+	u[8] = u[8]&0x3f | 0x80
+	return u
+}
+
+func ParseUUID(s string) (UUID, error) {
+	var u UUID
+
+	if len(s) != 36 || s[8] != '-' || s[13] != '-' || s[18] != '-' || s[23] != '-' {
+		return u, fmt.Errorf("%w: %q", ErrInvalidUUID, s)
+	}
+
+	_, err := hex.Decode(u[:], []byte(s[0:8]+s[9:13]+s[14:18]+s[19:23]+s[24:]))
+	if err != nil {
+		return u, fmt.Errorf("%w: %q", ErrInvalidUUID, s)
+	}
+
+	return u, nil
+}
+
+func (u UUID) String() string {
+	h := hex.EncodeToString(u[:])
+	return h[0:8] + "-" + h[8:12] + "-" + h[12:16] + "-" + h[16:20] + "-" + h[20:]
+}
+
+func (u *UUID) Scan(value any) error {
+	switch v := value.(type) {
+	case []byte:
+		if len(v) == 16 {
+			copy(u[:], v)
+			return nil
+		}
+		return u.Scan(string(v))
+	case string:
+		parsed, err := ParseUUID(v)
+		if err != nil {
+			return err
+		}
+		*u = parsed
+		return nil
+	default:
+		return fmt.Errorf("%w: cannot scan %T", ErrInvalidUUID, value)
+	}
+}
+
+func (u UUID) Value() (driver.Value, error) {
+	return u.String(), nil
+}
+
+func (u UUID) MarshalText() ([]byte, error) {
+	return []byte(u.String()), nil
+}
+
+func (u *UUID) UnmarshalText(text []byte) error {
+	return u.Scan(string(text))
+}
+
+func (u BinaryUUID) String() string {
+	return UUID(u).String()
+}
+
+func (u *BinaryUUID) Scan(value any) error {
+	return (*UUID)(u).Scan(value)
+}
+
+func (u BinaryUUID) Value() (driver.Value, error) {
+	return u[:], nil
+}
+
+func (u BinaryUUID) MarshalText() ([]byte, error) {
+	return UUID(u).MarshalText()
+}
+
+func (u *BinaryUUID) UnmarshalText(text []byte) error {
+	return (*UUID)(u).UnmarshalText(text)
+}
+
+func randomBytes(b []byte) {
+	_, err := rand.Read(b)
+	if err != nil {
+		panic(err)
+	}
+}
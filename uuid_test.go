@@ -0,0 +1,71 @@
+package db
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestUUID(t *testing.T) {
+	s := "0190f5a8-3c2e-7b4a-9f1d-2a6b8c0d4e5f"
+
+	u, err := ParseUUID(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if u.String() != s {
+		t.Errorf("expected %s, got %s", s, u.String())
+	}
+
+	var text, binary UUID
+	if err := text.Scan([]byte(s)); err != nil || text != u {
+		t.Errorf("could not scan the text form: %v", err)
+	}
+	if err := binary.Scan(u[:]); err != nil || binary != u {
+		t.Errorf("could not scan the binary form: %v", err)
+	}
+
+	if v, _ := u.Value(); v != s {
+		t.Errorf("expected UUID to be stored as text, got %v", v)
+	}
+
+	b := BinaryUUID(u)
+	if v, _ := b.Value(); string(v.([]byte)) != string(u[:]) {
+		t.Errorf("expected BinaryUUID to be stored as bytes, got %v", v)
+	}
+
+	for _, invalid := range []any{"not-a-uuid", "0190f5a8x3c2e-7b4a-9f1d-2a6b8c0d4e5f", 12} {
+		if err := text.Scan(invalid); !errors.Is(err, ErrInvalidUUID) {
+			t.Errorf("%v: expected ErrInvalidUUID, got %v", invalid, err)
+		}
+	}
+}
+
+func TestNewUUID(t *testing.T) {
+	v4 := NewUUIDv4()
+	if v4[6]>>4 != 4 || v4[8]>>6 != 2 {
+		t.Errorf("invalid v4 version or variant: %s", v4)
+	}
+
+	now := time.Date(2024, 3, 1, 12, 30, 15, 0, time.UTC)
+	SetClock(func() time.Time { return now })
+	defer SetClock(nil)
+
+	v7 := NewUUIDv7()
+	if v7[6]>>4 != 7 || v7[8]>>6 != 2 {
+		t.Errorf("invalid v7 version or variant: %s", v7)
+	}
+
+	var ms int64
+	for _, b := range v7[:6] {
+		ms = ms<<8 | int64(b)
+	}
+	if ms != now.UnixMilli() {
+		t.Errorf("expected v7 timestamp %d, got %d", now.UnixMilli(), ms)
+	}
+
+	if NewUUIDv4() == v4 {
+		t.Error("expected distinct uuids")
+	}
+}
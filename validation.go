@@ -105,6 +105,19 @@ func doFilter[T IEntity](entity *T, queryType QueryType) (map[string]any, error)
 	case Insert:
 		flat = entityToMap(entity, false, false, false)
 		var err error
+		check, omit := insertEnumColumns(entity)
+		if len(check) > 0 {
+			err = ValidateEnums(entity, check...)
+			if err != nil {
+				return flat, err
+			}
+		}
+		for _, c := range omit {
+			delete(flat, c)
+		}
+		if err = checkValues(entity, check); err != nil {
+			return flat, err
+		}
 		if e, ok := any(entity).(IFilter); ok {
 			err = e.Filter(flat)
 			if err != nil {
@@ -118,8 +131,21 @@ func doFilter[T IEntity](entity *T, queryType QueryType) (map[string]any, error)
 			}
 		}
 	case Update:
-		flat = entityToMap(entity, true, false, false)
+		var tracked bool
+		flat, tracked = changedFields(entity)
+		if !tracked {
+			flat = entityToMap(entity, true, false, false)
+		}
 		var err error
+		if columns := updateEnumColumns(entity, flat); len(columns) > 0 {
+			err = ValidateEnums(entity, columns...)
+			if err != nil {
+				return flat, err
+			}
+			if err = checkValues(entity, columns); err != nil {
+				return flat, err
+			}
+		}
 		if e, ok := any(entity).(IFilter); ok {
 			err = e.Filter(flat)
 			if err != nil {
@@ -136,3 +162,26 @@ func doFilter[T IEntity](entity *T, queryType QueryType) (map[string]any, error)
 
 	return flat, nil
 }
+
+// checkValues returns the first error of the given mapped Valuer fields, as mapping drops the values a Valuer
+// refuses to write and the write would otherwise go through without them
+func checkValues[T IEntity](entity *T, columns []string) error {
+	v := reflect.ValueOf(entity).Elem()
+
+	for _, f := range getEntityMeta(v.Type()).Fields {
+		if f.Column == "" || f.Foreign != "" || !slices.Contains(columns, f.Column) {
+			continue
+		}
+
+		valuer, ok := v.Field(f.Index).Interface().(driver.Valuer)
+		if !ok {
+			continue
+		}
+
+		if _, err := valuer.Value(); err != nil {
+			return fmt.Errorf("%s: %w", f.Column, err)
+		}
+	}
+
+	return nil
+}
@@ -185,12 +185,16 @@ func RValue(value any) Value {
 		return val
 	case Float:
 		return val
+	case Bool:
+		return val
 	case List:
 		return val
 	case Time:
 		return val
 	case *QueryBuilder:
 		return val
+	case Decimal:
+		return val
 	case driver.Valuer:
 		v, err := val.Value()
 		if err != nil {
@@ -0,0 +1,45 @@
+package db
+
+import (
+	"fmt"
+	"reflect"
+	"time"
+)
+
+// incrementVersion returns the next value of a version field: integers are incremented, times are set to now at the
+// fractional second precision of the version column, or one step of it past the current version
+func incrementVersion(version reflect.Value, precision int64) reflect.Value {
+	next := reflect.New(version.Type()).Elem()
+
+	switch version.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		next.SetInt(version.Int() + 1)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		next.SetUint(version.Uint() + 1)
+	default:
+		if version.Type() != typeOf[time.Time]() {
+			panic("version field must be an integer or a time.Time, got " + version.Type().String())
+		}
+
+		now := truncateTime(clock(), precision)
+		if current := version.Interface().(time.Time); !now.After(current) {
+			now = truncateTime(current, precision).Add(precisionUnit(precision))
+		}
+		next.Set(reflect.ValueOf(now))
+	}
+
+	return next
+}
+
+func checkVersion(r *Result, table string, id any, version reflect.Value) error {
+	n, err := r.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if n == 0 {
+		return fmt.Errorf("%w: %s %v is no longer at version %v", ErrStaleEntity, table, id, version.Interface())
+	}
+
+	return nil
+}
@@ -0,0 +1,62 @@
+package db
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestIncrementVersion(t *testing.T) {
+	if v := incrementVersion(reflect.ValueOf(int64(4)), 0).Interface(); v != int64(5) {
+		t.Errorf("expected 5, got %v", v)
+	}
+
+	if v := incrementVersion(reflect.ValueOf(uint(1)), 0).Interface(); v != uint(2) {
+		t.Errorf("expected 2, got %v", v)
+	}
+
+	future := time.Now().Add(time.Hour).Truncate(time.Second)
+	if v := incrementVersion(reflect.ValueOf(future), 0).Interface().(time.Time); !v.After(future) {
+		t.Errorf("expected a time after %v, got %v", future, v)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("expected a panic for a string version")
+		}
+	}()
+	incrementVersion(reflect.ValueOf("1"), 0)
+}
+
+func TestIncrementVersion_Precision(t *testing.T) {
+	now := time.Date(2024, 3, 1, 12, 30, 15, 123456789, time.UTC)
+	SetClock(func() time.Time { return now })
+	defer SetClock(nil)
+
+	first := incrementVersion(reflect.ValueOf(time.Time{}), 3).Interface().(time.Time)
+	if !first.Equal(time.Date(2024, 3, 1, 12, 30, 15, 123000000, time.UTC)) {
+		t.Errorf("expected the version to keep milliseconds, got %v", first)
+	}
+
+	second := incrementVersion(reflect.ValueOf(first), 3).Interface().(time.Time)
+	if !second.Equal(first.Add(time.Millisecond)) {
+		t.Errorf("expected an update within the same millisecond to advance the version by one, got %v", second)
+	}
+
+	micro := incrementVersion(reflect.ValueOf(time.Time{}), 6).Interface().(time.Time)
+	if !micro.Equal(time.Date(2024, 3, 1, 12, 30, 15, 123456000, time.UTC)) {
+		t.Errorf("expected the version to keep microseconds, got %v", micro)
+	}
+}
+
+func TestEntityMeta_Version(t *testing.T) {
+	m := getEntityMeta(typeOf[Document]())
+
+	if !m.HasVersion || m.Version.Column != "document_version" {
+		t.Errorf("expected document_version to be the version field")
+	}
+
+	if getEntityMeta(typeOf[Child]()).HasVersion {
+		t.Error("Child should not be versioned")
+	}
+}